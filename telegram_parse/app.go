@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -17,7 +19,6 @@ import (
 type App struct {
 	ctx     context.Context
 	scanner *fileops.Scanner
-	parser  *parser.JSONToMarkdown
 
 	// Processing state
 	mu              sync.Mutex
@@ -30,7 +31,6 @@ type App struct {
 func NewApp() *App {
 	return &App{
 		scanner: fileops.NewScanner(),
-		parser:  parser.NewJSONToMarkdown(),
 	}
 }
 
@@ -62,9 +62,40 @@ func (a *App) SelectDirectory() (string, error) {
 	return directory, nil
 }
 
-// ScanDirectory scans the selected directory for JSON files
-func (a *App) ScanDirectory(dirPath string, includeSubdirs bool) ([]models.FileInfo, error) {
-	files, err := a.scanner.ScanDirectory(dirPath, includeSubdirs)
+// SelectOutputDirectory opens a dialog to choose where output files are written
+func (a *App) SelectOutputDirectory() (string, error) {
+	directory, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "Select output directory",
+		CanCreateDirectories: true,
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to open directory dialog: %w", err)
+	}
+
+	return directory, nil
+}
+
+// SelectTemplateFile opens a dialog to choose a Go template for template output
+func (a *App) SelectTemplateFile() (string, error) {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select output template",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Go templates (*.tmpl, *.tpl, *.gotmpl)", Pattern: "*.tmpl;*.tpl;*.gotmpl"},
+			{DisplayName: "All files", Pattern: "*"},
+		},
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to open file dialog: %w", err)
+	}
+
+	return file, nil
+}
+
+// ScanDirectory scans the source directory for JSON files using scan options
+func (a *App) ScanDirectory(options models.ProcessOptions) ([]models.FileInfo, error) {
+	files, err := a.scanner.ScanDirectoryWithOptions(options.SourceDir, scanOptions(options))
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan directory: %w", err)
 	}
@@ -72,6 +103,18 @@ func (a *App) ScanDirectory(dirPath string, includeSubdirs bool) ([]models.FileI
 	return files, nil
 }
 
+// scanOptions extracts directory scanning options from process options
+func scanOptions(options models.ProcessOptions) fileops.ScanOptions {
+	return fileops.ScanOptions{
+		IncludeSubdirs:  options.IncludeSubdirs,
+		IncludePatterns: options.IncludePatterns,
+		ExcludePatterns: options.ExcludePatterns,
+		MaxDepth:        options.MaxDepth,
+		FollowSymlinks:  options.FollowSymlinks,
+		SkipHidden:      options.SkipHidden,
+	}
+}
+
 // ProcessFiles starts processing JSON files to Markdown
 func (a *App) ProcessFiles(options models.ProcessOptions) error {
 	a.mu.Lock()
@@ -82,15 +125,45 @@ func (a *App) ProcessFiles(options models.ProcessOptions) error {
 	}
 
 	// Scan for files
-	files, err := a.scanner.ScanDirectory(options.SourceDir, options.IncludeSubdirs)
+	scanned, err := a.scanner.ScanDirectoryWithOptions(options.SourceDir, scanOptions(options))
 	if err != nil {
 		return fmt.Errorf("failed to scan directory: %w", err)
 	}
 
-	if len(files) == 0 {
+	if len(scanned) == 0 {
 		return fmt.Errorf("no JSON files found in directory")
 	}
 
+	// Skip JSON files that are not Telegram exports
+	var files []models.FileInfo
+	for _, file := range scanned {
+		if file.Kind != fileops.KindNotExport {
+			files = append(files, file)
+		}
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no Telegram export files found in directory")
+	}
+
+	if options.OutputFormat == parser.FormatTemplate && options.TemplatePath == "" {
+		return fmt.Errorf("no template file selected")
+	}
+
+	// All chats of a run are written into one database in SQLite mode
+	var database *parser.Database
+	if options.OutputFormat == parser.FormatSQLite {
+		databasePath := databasePath(options)
+		if err := os.MkdirAll(filepath.Dir(databasePath), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		database, err = parser.OpenDatabase(databasePath)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Initialize progress
 	a.currentProgress = models.Progress{
 		TotalFiles:     len(files),
@@ -107,14 +180,37 @@ func (a *App) ProcessFiles(options models.ProcessOptions) error {
 	a.isProcessing = true
 
 	// Start processing in background
-	go a.processFilesBackground(ctx, files, options)
+	go a.processFilesBackground(ctx, files, options, database)
 
 	return nil
 }
 
+// databasePath returns the SQLite database path, in the output directory or
+// the source directory
+func databasePath(options models.ProcessOptions) string {
+	dir := options.OutputDir
+	if dir == "" {
+		dir = options.SourceDir
+	}
+	return filepath.Join(dir, parser.DatabaseFileName)
+}
+
+// outputExtension returns the extension of output files, given by the
+// template file name in template mode
+func outputExtension(options models.ProcessOptions) string {
+	if options.OutputFormat == parser.FormatTemplate {
+		return parser.TemplateExtension(options.TemplatePath)
+	}
+	return parser.OutputExtension(options.OutputFormat)
+}
+
 // processFilesBackground handles file processing in background
-func (a *App) processFilesBackground(ctx context.Context, files []models.FileInfo, options models.ProcessOptions) {
+func (a *App) processFilesBackground(ctx context.Context, files []models.FileInfo, options models.ProcessOptions, database *parser.Database) {
 	defer func() {
+		if database != nil {
+			database.Close()
+		}
+
 		a.mu.Lock()
 		a.isProcessing = false
 		a.currentProgress.IsActive = false
@@ -124,10 +220,26 @@ func (a *App) processFilesBackground(ctx context.Context, files []models.FileInf
 	var (
 		successCount  int
 		errorCount    int
+		skippedCount  int
 		errors        []models.FileError
+		skippedFiles  []string
+		missingMedia  []models.MissingMedia
 		processedSize int64
 	)
 
+	// Converter configured for this job
+	converter := parser.NewJSONToMarkdownWithOptions(options)
+	if database != nil {
+		converter.SetDatabase(database)
+	}
+
+	// Load results of previous runs for incremental conversion
+	var cache *fileops.Cache
+	fingerprint := fileops.OptionsFingerprint(options)
+	if options.Incremental {
+		cache = fileops.LoadCache(options.SourceDir)
+	}
+
 	// Process files with concurrency limit
 	maxConcurrency := options.MaxConcurrency
 	if maxConcurrency <= 0 {
@@ -138,11 +250,12 @@ func (a *App) processFilesBackground(ctx context.Context, files []models.FileInf
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
+queue:
 	for i, file := range files {
 		select {
 		case <-ctx.Done():
-			// Processing was cancelled
-			return
+			// Processing was cancelled, wait for started files below
+			break queue
 		default:
 		}
 
@@ -154,12 +267,36 @@ func (a *App) processFilesBackground(ctx context.Context, files []models.FileInf
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
+			// Files still waiting when the job is cancelled are not started
+			if ctx.Err() != nil {
+				return
+			}
+
 			// Update current file progress
 			a.updateProgress(fileInfo.Name, index)
 
+			// Skip files unchanged since the previous run
+			if cache != nil && cache.Unchanged(fileInfo, fingerprint) {
+				mu.Lock()
+				skippedCount++
+				skippedFiles = append(skippedFiles, fileInfo.Path)
+				mu.Unlock()
+				return
+			}
+
 			// Process the file
-			outputPath := a.scanner.CreateOutputPath(fileInfo.Path)
-			err := a.parser.ConvertFile(fileInfo.Path, outputPath)
+			outputPath := a.scanner.CreateOutputPath(fileInfo, options.SourceDir, options.OutputDir, outputExtension(options))
+			if database != nil {
+				outputPath = databasePath(options)
+			}
+			report, err := a.convertFile(converter, fileInfo, outputPath)
+
+			// Remember converted file for the next run
+			if err == nil && cache != nil {
+				if hash, hashErr := fileops.HashFile(fileInfo); hashErr == nil {
+					cache.Record(fileInfo, fingerprint, hash, report.OutputPath)
+				}
+			}
 
 			// Update results
 			mu.Lock()
@@ -172,6 +309,12 @@ func (a *App) processFilesBackground(ctx context.Context, files []models.FileInf
 			} else {
 				successCount++
 				processedSize += fileInfo.Size
+				for _, mediaPath := range report.MissingMedia {
+					missingMedia = append(missingMedia, models.MissingMedia{
+						FilePath:  fileInfo.Path,
+						MediaPath: mediaPath,
+					})
+				}
 			}
 			mu.Unlock()
 
@@ -180,21 +323,61 @@ func (a *App) processFilesBackground(ctx context.Context, files []models.FileInf
 
 	wg.Wait()
 
+	// Media inventory of all chats converted into the database
+	if database != nil && options.MediaInventory {
+		if err := database.WriteInventory(databasePath(options), options.CSVBOM); err != nil {
+			errorCount++
+			errors = append(errors, models.FileError{
+				FilePath: databasePath(options),
+				Error:    err.Error(),
+			})
+		}
+	}
+
+	if cache != nil {
+		// The next run would convert everything again, so this fails the job
+		if err := cache.Save(); err != nil {
+			errorCount++
+			errors = append(errors, models.FileError{
+				FilePath: options.SourceDir,
+				Error:    err.Error(),
+			})
+		}
+	}
+
 	// Send final result
 	result := models.ProcessResult{
 		Success:       errorCount == 0,
 		TotalFiles:    len(files),
 		SuccessCount:  successCount,
 		ErrorCount:    errorCount,
+		SkippedCount:  skippedCount,
 		ProcessedSize: processedSize,
 		Duration:      time.Since(a.currentProgress.StartTime),
 		Errors:        errors,
+		SkippedFiles:  skippedFiles,
+		MissingMedia:  missingMedia,
 	}
 
 	// Emit completion event
 	runtime.EventsEmit(a.ctx, "processing-complete", result)
 }
 
+// convertFile converts a scanned file, reading archive entries in place
+func (a *App) convertFile(converter *parser.JSONToMarkdown, fileInfo models.FileInfo, outputPath string) (*parser.ConvertReport, error) {
+	if fileInfo.ArchivePath == "" {
+		return converter.ConvertFile(fileInfo.Path, outputPath)
+	}
+
+	fsys, name, closer, err := fileops.SourceFS(fileInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	return converter.ConvertFS(fsys, name, fileInfo.Path, outputPath)
+}
+
 // updateProgress updates processing progress and emits event
 func (a *App) updateProgress(currentFile string, processedIndex int) {
 	a.mu.Lock()
@@ -3,6 +3,8 @@ import './app.css';
 
 import {
     SelectDirectory,
+    SelectOutputDirectory,
+    SelectTemplateFile,
     ScanDirectory,
     ProcessFiles,
     CancelProcessing
@@ -13,17 +15,39 @@ import { EventsOn } from '../wailsjs/runtime/runtime';
 // Application state
 interface AppState {
     selectedDirectory: string;
+    outputDirectory: string;
     files: any[];
     isProcessing: boolean;
     progress: any;
     results: any;
     showResults: boolean;
     includeSubdirs: boolean;
+    incremental: boolean;
     maxConcurrency: number;
+    includePatterns: string;
+    excludePatterns: string;
+    maxDepth: number;
+    followSymlinks: boolean;
+    skipHidden: boolean;
+    mediaMode: string;
+    embedImages: boolean;
+    inlineImageMaxKB: number;
+    mediaInventory: boolean;
+    topicMode: string;
+    layout: string;
+    outputFormat: string;
+    sitePages: string;
+    csvBOM: boolean;
+    markdownFlavor: string;
+    templatePath: string;
+    language: string;
+    headerStyle: string;
+    headerFields: string[];
 }
 
 const state: AppState = {
     selectedDirectory: '',
+    outputDirectory: '',
     files: [],
     isProcessing: false,
     progress: {
@@ -37,13 +61,37 @@ const state: AppState = {
     results: null,
     showResults: false,
     includeSubdirs: false,
-    maxConcurrency: 4
+    incremental: false,
+    maxConcurrency: 4,
+    includePatterns: '',
+    excludePatterns: '',
+    maxDepth: 0,
+    followSymlinks: false,
+    skipHidden: false,
+    mediaMode: 'reference',
+    embedImages: false,
+    inlineImageMaxKB: 0,
+    mediaInventory: false,
+    topicMode: 'chronological',
+    layout: 'chronological',
+    outputFormat: 'markdown',
+    sitePages: 'month',
+    csvBOM: false,
+    markdownFlavor: 'gfm',
+    templatePath: '',
+    language: 'en',
+    headerStyle: 'markdown',
+    headerFields: []
 };
 
 // DOM elements
 let selectDirBtn: HTMLButtonElement;
 let selectedDirSpan: HTMLSpanElement;
+let selectOutputDirBtn: HTMLButtonElement;
+let clearOutputDirBtn: HTMLButtonElement;
+let outputDirSpan: HTMLSpanElement;
 let fileCountSpan: HTMLSpanElement;
+let fileListDiv: HTMLDivElement;
 let processBtn: HTMLButtonElement;
 let cancelBtn: HTMLButtonElement;
 let progressContainer: HTMLDivElement;
@@ -52,7 +100,28 @@ let progressText: HTMLSpanElement;
 let currentFileSpan: HTMLSpanElement;
 let resultsContainer: HTMLDivElement;
 let includeSubdirsCheckbox: HTMLInputElement;
+let incrementalCheckbox: HTMLInputElement;
 let maxConcurrencyInput: HTMLInputElement;
+let includePatternsInput: HTMLInputElement;
+let excludePatternsInput: HTMLInputElement;
+let maxDepthInput: HTMLInputElement;
+let followSymlinksCheckbox: HTMLInputElement;
+let skipHiddenCheckbox: HTMLInputElement;
+let mediaModeSelect: HTMLSelectElement;
+let embedImagesCheckbox: HTMLInputElement;
+let inlineImageMaxInput: HTMLInputElement;
+let mediaInventoryCheckbox: HTMLInputElement;
+let topicModeSelect: HTMLSelectElement;
+let layoutSelect: HTMLSelectElement;
+let outputFormatSelect: HTMLSelectElement;
+let sitePagesSelect: HTMLSelectElement;
+let csvBOMCheckbox: HTMLInputElement;
+let markdownFlavorSelect: HTMLSelectElement;
+let languageSelect: HTMLSelectElement;
+let headerStyleSelect: HTMLSelectElement;
+let headerFieldCheckboxes: NodeListOf<HTMLInputElement>;
+let selectTemplateBtn: HTMLButtonElement;
+let templatePathSpan: HTMLSpanElement;
 
 // Initialize the application
 document.querySelector('#app')!.innerHTML = `
@@ -75,7 +144,26 @@ document.querySelector('#app')!.innerHTML = `
                     </span>
                 </div>
                 <p class="help-text">
-                    Select a directory containing Telegram JSON export files (result.json)
+                    Select a directory containing Telegram JSON export files (result.json) or ZIP archives with exports
+                </p>
+            </div>
+
+            <!-- Output Directory -->
+            <div class="section">
+                <h2>📂 Output Directory</h2>
+                <div class="directory-selector">
+                    <button id="selectOutputDirBtn" class="btn btn-primary">
+                        Choose Output Directory
+                    </button>
+                    <button id="clearOutputDirBtn" class="btn btn-danger" style="display: none;">
+                        Clear
+                    </button>
+                    <span id="outputDir" class="directory-path">
+                        Next to source files
+                    </span>
+                </div>
+                <p class="help-text">
+                    Optional. Markdown files are written next to the JSON files or archives by default
                 </p>
             </div>
 
@@ -88,20 +176,207 @@ document.querySelector('#app')!.innerHTML = `
                         <span class="checkmark"></span>
                         Include subdirectories
                     </label>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" id="incremental">
+                        <span class="checkmark"></span>
+                        Skip unchanged files
+                    </label>
                     
                     <div class="input-group">
                         <label for="maxConcurrency">Max concurrent files:</label>
                         <input type="number" id="maxConcurrency" min="1" max="10" value="4" class="input-number">
                     </div>
+
+                    <div class="input-group">
+                        <label for="mediaMode">Media files:</label>
+                        <select id="mediaMode" class="input-select">
+                            <option value="reference">Mention file names</option>
+                            <option value="copy">Copy into assets folder</option>
+                            <option value="hardlink">Hardlink into assets folder</option>
+                        </select>
+                    </div>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" id="embedImages">
+                        <span class="checkmark"></span>
+                        Show photos and stickers as images
+                    </label>
+
+                    <div class="input-group">
+                        <label for="inlineImageMax">Inline images up to (KB, 0 = off):</label>
+                        <input type="number" id="inlineImageMax" min="0" max="10240" value="0" class="input-number">
+                    </div>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" id="mediaInventory">
+                        <span class="checkmark"></span>
+                        Media inventory (appendix and CSV)
+                    </label>
+
+                    <div class="input-group">
+                        <label for="topicMode">Forum topics:</label>
+                        <select id="topicMode" class="input-select">
+                            <option value="chronological">Chronological</option>
+                            <option value="sections">Section per topic</option>
+                            <option value="files">File per topic</option>
+                        </select>
+                    </div>
+
+                    <div class="input-group">
+                        <label for="layout">Message layout:</label>
+                        <select id="layout" class="input-select">
+                            <option value="chronological">Chronological</option>
+                            <option value="threaded">Threads (replies nested)</option>
+                            <option value="channel">Channel posts as articles</option>
+                        </select>
+                    </div>
+
+                    <div class="input-group">
+                        <label for="outputFormat">Output format:</label>
+                        <select id="outputFormat" class="input-select">
+                            <option value="markdown">Markdown</option>
+                            <option value="obsidian">Obsidian vault</option>
+                            <option value="hugo">Hugo site</option>
+                            <option value="jekyll">Jekyll site</option>
+                            <option value="jsonl">JSONL records</option>
+                            <option value="csv">CSV tables</option>
+                            <option value="sqlite">SQLite database</option>
+                            <option value="epub">EPUB e-book</option>
+                            <option value="org">Org-mode</option>
+                            <option value="asciidoc">AsciiDoc</option>
+                            <option value="text">Plain-text transcript</option>
+                            <option value="template">Custom template</option>
+                        </select>
+                    </div>
+
+                    <div class="directory-selector">
+                        <button id="selectTemplateBtn" class="btn btn-primary">
+                            Choose Template
+                        </button>
+                        <span id="templatePath" class="directory-path">
+                            No template selected
+                        </span>
+                    </div>
+
+                    <div class="input-group">
+                        <label for="markdownFlavor">Markdown flavor:</label>
+                        <select id="markdownFlavor" class="input-select">
+                            <option value="commonmark">CommonMark</option>
+                            <option value="gfm" selected>GitHub (GFM)</option>
+                            <option value="telegram">Telegram MarkdownV2</option>
+                            <option value="discord">Discord</option>
+                        </select>
+                    </div>
+
+                    <div class="input-group">
+                        <label for="language">Output language:</label>
+                        <select id="language" class="input-select">
+                            <option value="en" selected>English</option>
+                            <option value="ru">Русский</option>
+                        </select>
+                    </div>
+
+                    <div class="input-group">
+                        <label for="sitePages">Site pages:</label>
+                        <select id="sitePages" class="input-select">
+                            <option value="post">Page per post</option>
+                            <option value="day">Page per day</option>
+                            <option value="month" selected>Page per month</option>
+                        </select>
+                    </div>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" id="csvBOM">
+                        <span class="checkmark"></span>
+                        CSV for Excel (UTF-8 BOM)
+                    </label>
+
+                    <div class="input-group">
+                        <label for="headerStyle">Chat header:</label>
+                        <select id="headerStyle" class="input-select">
+                            <option value="markdown" selected>Markdown fields</option>
+                            <option value="yaml">YAML front matter</option>
+                            <option value="toml">TOML front matter</option>
+                            <option value="none">No header</option>
+                        </select>
+                    </div>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" class="header-field" value="dates">
+                        <span class="checkmark"></span>
+                        Header: date range
+                    </label>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" class="header-field" value="participants">
+                        <span class="checkmark"></span>
+                        Header: participants with message counts
+                    </label>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" class="header-field" value="source">
+                        <span class="checkmark"></span>
+                        Header: source file path
+                    </label>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" class="header-field" value="generated">
+                        <span class="checkmark"></span>
+                        Header: conversion time
+                    </label>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" class="header-field" value="version">
+                        <span class="checkmark"></span>
+                        Header: converter version
+                    </label>
                 </div>
             </div>
 
+            <!-- Scan Filters -->
+            <div class="section">
+                <h2>🔍 Scan Filters</h2>
+                <div class="options">
+                    <div class="input-group">
+                        <label for="includePatterns">Include patterns:</label>
+                        <input type="text" id="includePatterns" placeholder="result.json, chats/**/*.json" class="input-text">
+                    </div>
+
+                    <div class="input-group">
+                        <label for="excludePatterns">Exclude patterns:</label>
+                        <input type="text" id="excludePatterns" placeholder="media/, stickers/, backup*/" class="input-text">
+                    </div>
+
+                    <div class="input-group">
+                        <label for="maxDepth">Max depth (0 = unlimited):</label>
+                        <input type="number" id="maxDepth" min="0" max="100" value="0" class="input-number">
+                    </div>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" id="followSymlinks">
+                        <span class="checkmark"></span>
+                        Follow symbolic links
+                    </label>
+
+                    <label class="checkbox-label">
+                        <input type="checkbox" id="skipHidden">
+                        <span class="checkmark"></span>
+                        Skip hidden directories
+                    </label>
+                </div>
+                <p class="help-text">
+                    Comma-separated glob patterns. Patterns ending with "/" match directories, "**" matches nested directories
+                </p>
+            </div>
+
             <!-- File Information -->
             <div class="section" id="fileSection" style="display: none;">
                 <h2>📋 Found Files</h2>
                 <div class="file-info">
                     <span id="fileCount" class="file-count">0 JSON files found</span>
                 </div>
+                <div id="fileList" class="file-list"></div>
             </div>
 
             <!-- Processing Controls -->
@@ -144,7 +419,11 @@ document.querySelector('#app')!.innerHTML = `
 // Get DOM elements
 selectDirBtn = document.getElementById('selectDirBtn') as HTMLButtonElement;
 selectedDirSpan = document.getElementById('selectedDir') as HTMLSpanElement;
+selectOutputDirBtn = document.getElementById('selectOutputDirBtn') as HTMLButtonElement;
+clearOutputDirBtn = document.getElementById('clearOutputDirBtn') as HTMLButtonElement;
+outputDirSpan = document.getElementById('outputDir') as HTMLSpanElement;
 fileCountSpan = document.getElementById('fileCount') as HTMLSpanElement;
+fileListDiv = document.getElementById('fileList') as HTMLDivElement;
 processBtn = document.getElementById('processBtn') as HTMLButtonElement;
 cancelBtn = document.getElementById('cancelBtn') as HTMLButtonElement;
 progressContainer = document.getElementById('progressSection') as HTMLDivElement;
@@ -153,10 +432,34 @@ progressText = document.getElementById('progressText') as HTMLSpanElement;
 currentFileSpan = document.getElementById('currentFile') as HTMLSpanElement;
 resultsContainer = document.getElementById('resultsContainer') as HTMLDivElement;
 includeSubdirsCheckbox = document.getElementById('includeSubdirs') as HTMLInputElement;
+incrementalCheckbox = document.getElementById('incremental') as HTMLInputElement;
 maxConcurrencyInput = document.getElementById('maxConcurrency') as HTMLInputElement;
+includePatternsInput = document.getElementById('includePatterns') as HTMLInputElement;
+excludePatternsInput = document.getElementById('excludePatterns') as HTMLInputElement;
+maxDepthInput = document.getElementById('maxDepth') as HTMLInputElement;
+followSymlinksCheckbox = document.getElementById('followSymlinks') as HTMLInputElement;
+skipHiddenCheckbox = document.getElementById('skipHidden') as HTMLInputElement;
+mediaModeSelect = document.getElementById('mediaMode') as HTMLSelectElement;
+embedImagesCheckbox = document.getElementById('embedImages') as HTMLInputElement;
+inlineImageMaxInput = document.getElementById('inlineImageMax') as HTMLInputElement;
+mediaInventoryCheckbox = document.getElementById('mediaInventory') as HTMLInputElement;
+topicModeSelect = document.getElementById('topicMode') as HTMLSelectElement;
+layoutSelect = document.getElementById('layout') as HTMLSelectElement;
+outputFormatSelect = document.getElementById('outputFormat') as HTMLSelectElement;
+sitePagesSelect = document.getElementById('sitePages') as HTMLSelectElement;
+csvBOMCheckbox = document.getElementById('csvBOM') as HTMLInputElement;
+markdownFlavorSelect = document.getElementById('markdownFlavor') as HTMLSelectElement;
+languageSelect = document.getElementById('language') as HTMLSelectElement;
+headerStyleSelect = document.getElementById('headerStyle') as HTMLSelectElement;
+headerFieldCheckboxes = document.querySelectorAll<HTMLInputElement>('.header-field');
+selectTemplateBtn = document.getElementById('selectTemplateBtn') as HTMLButtonElement;
+templatePathSpan = document.getElementById('templatePath') as HTMLSpanElement;
 
 // Event listeners
 selectDirBtn.addEventListener('click', selectDirectory);
+selectOutputDirBtn.addEventListener('click', selectOutputDirectory);
+selectTemplateBtn.addEventListener('click', selectTemplateFile);
+clearOutputDirBtn.addEventListener('click', clearOutputDirectory);
 processBtn.addEventListener('click', startProcessing);
 cancelBtn.addEventListener('click', cancelProcessing);
 
@@ -167,10 +470,95 @@ includeSubdirsCheckbox.addEventListener('change', (e) => {
     }
 });
 
+incrementalCheckbox.addEventListener('change', (e) => {
+    state.incremental = (e.target as HTMLInputElement).checked;
+});
+
 maxConcurrencyInput.addEventListener('change', (e) => {
     state.maxConcurrency = parseInt((e.target as HTMLInputElement).value);
 });
 
+mediaModeSelect.addEventListener('change', (e) => {
+    state.mediaMode = (e.target as HTMLSelectElement).value;
+});
+
+embedImagesCheckbox.addEventListener('change', (e) => {
+    state.embedImages = (e.target as HTMLInputElement).checked;
+});
+
+inlineImageMaxInput.addEventListener('change', (e) => {
+    state.inlineImageMaxKB = parseInt((e.target as HTMLInputElement).value) || 0;
+});
+
+mediaInventoryCheckbox.addEventListener('change', (e) => {
+    state.mediaInventory = (e.target as HTMLInputElement).checked;
+});
+
+topicModeSelect.addEventListener('change', (e) => {
+    state.topicMode = (e.target as HTMLSelectElement).value;
+});
+
+layoutSelect.addEventListener('change', (e) => {
+    state.layout = (e.target as HTMLSelectElement).value;
+});
+
+outputFormatSelect.addEventListener('change', (e) => {
+    state.outputFormat = (e.target as HTMLSelectElement).value;
+});
+
+sitePagesSelect.addEventListener('change', (e) => {
+    state.sitePages = (e.target as HTMLSelectElement).value;
+});
+
+csvBOMCheckbox.addEventListener('change', (e) => {
+    state.csvBOM = (e.target as HTMLInputElement).checked;
+});
+
+markdownFlavorSelect.addEventListener('change', (e) => {
+    state.markdownFlavor = (e.target as HTMLSelectElement).value;
+});
+
+languageSelect.addEventListener('change', (e) => {
+    state.language = (e.target as HTMLSelectElement).value;
+});
+
+headerStyleSelect.addEventListener('change', (e) => {
+    state.headerStyle = (e.target as HTMLSelectElement).value;
+});
+
+headerFieldCheckboxes.forEach((checkbox) => {
+    checkbox.addEventListener('change', () => {
+        state.headerFields = Array.from(headerFieldCheckboxes)
+            .filter((field) => field.checked)
+            .map((field) => field.value);
+    });
+});
+
+includePatternsInput.addEventListener('change', (e) => {
+    state.includePatterns = (e.target as HTMLInputElement).value;
+    rescanDirectory();
+});
+
+excludePatternsInput.addEventListener('change', (e) => {
+    state.excludePatterns = (e.target as HTMLInputElement).value;
+    rescanDirectory();
+});
+
+maxDepthInput.addEventListener('change', (e) => {
+    state.maxDepth = parseInt((e.target as HTMLInputElement).value) || 0;
+    rescanDirectory();
+});
+
+followSymlinksCheckbox.addEventListener('change', (e) => {
+    state.followSymlinks = (e.target as HTMLInputElement).checked;
+    rescanDirectory();
+});
+
+skipHiddenCheckbox.addEventListener('change', (e) => {
+    state.skipHidden = (e.target as HTMLInputElement).checked;
+    rescanDirectory();
+});
+
 // Listen for backend events
 EventsOn('processing-progress', (progress: any) => {
     updateProgress(progress);
@@ -196,26 +584,69 @@ async function selectDirectory() {
     }
 }
 
+async function selectOutputDirectory() {
+    try {
+        const directory = await SelectOutputDirectory();
+        if (directory) {
+            state.outputDirectory = directory;
+            outputDirSpan.textContent = directory;
+            outputDirSpan.className = 'directory-path selected';
+            clearOutputDirBtn.style.display = 'inline-block';
+        }
+    } catch (error) {
+        console.error('Error selecting output directory:', error);
+        alert('Error selecting output directory: ' + error);
+    }
+}
+
+async function selectTemplateFile() {
+    try {
+        const file = await SelectTemplateFile();
+        if (file) {
+            state.templatePath = file;
+            templatePathSpan.textContent = file;
+            templatePathSpan.className = 'directory-path selected';
+        }
+    } catch (error) {
+        console.error('Error selecting template:', error);
+        alert('Error selecting template: ' + error);
+    }
+}
+
+function clearOutputDirectory() {
+    state.outputDirectory = '';
+    outputDirSpan.textContent = 'Next to source files';
+    outputDirSpan.className = 'directory-path';
+    clearOutputDirBtn.style.display = 'none';
+}
+
 async function scanDirectory() {
     if (!state.selectedDirectory) return;
     
     try {
-        const files = await ScanDirectory(state.selectedDirectory, state.includeSubdirs);
+        const files = await ScanDirectory(buildOptions());
         state.files = files;
         
-        fileCountSpan.textContent = `${files.length} JSON files found`;
+        // Files that are not Telegram exports are skipped during processing
+        const exports = files.filter((file: any) => file.kind !== 'unknown');
+        const skipped = files.length - exports.length;
+
+        fileCountSpan.textContent = skipped > 0
+            ? `${exports.length} Telegram exports found (${skipped} other JSON files will be skipped)`
+            : `${exports.length} Telegram exports found`;
+        displayFileList(files);
         
         const fileSection = document.getElementById('fileSection')!;
         const controlsSection = document.getElementById('controlsSection')!;
         
-        if (files.length > 0) {
+        if (exports.length > 0) {
             fileSection.style.display = 'block';
             controlsSection.style.display = 'block';
             processBtn.disabled = false;
         } else {
             fileSection.style.display = 'block';
             controlsSection.style.display = 'none';
-            alert('No JSON files found in the selected directory.');
+            alert('No Telegram export files found in the selected directory.');
         }
     } catch (error) {
         console.error('Error scanning directory:', error);
@@ -223,15 +654,51 @@ async function scanDirectory() {
     }
 }
 
+// buildOptions collects processing options from the UI state
+function buildOptions() {
+    return {
+        sourceDir: state.selectedDirectory,
+        outputDir: state.outputDirectory,
+        maxConcurrency: state.maxConcurrency,
+        includeSubdirs: state.includeSubdirs,
+        incremental: state.incremental,
+        includePatterns: splitPatterns(state.includePatterns),
+        excludePatterns: splitPatterns(state.excludePatterns),
+        maxDepth: state.maxDepth,
+        followSymlinks: state.followSymlinks,
+        skipHidden: state.skipHidden,
+        mediaMode: state.mediaMode,
+        embedImages: state.embedImages,
+        inlineImageMaxKB: state.inlineImageMaxKB,
+        mediaInventory: state.mediaInventory,
+        topicMode: state.topicMode,
+        layout: state.layout,
+        outputFormat: state.outputFormat,
+        sitePages: state.sitePages,
+        csvBOM: state.csvBOM,
+        markdownFlavor: state.markdownFlavor,
+        templatePath: state.templatePath,
+        language: state.language,
+        headerStyle: state.headerStyle,
+        headerFields: state.headerFields
+    };
+}
+
+function splitPatterns(value: string): string[] {
+    return value.split(',').map((pattern) => pattern.trim()).filter(Boolean);
+}
+
+function rescanDirectory() {
+    if (state.selectedDirectory) {
+        scanDirectory();
+    }
+}
+
 async function startProcessing() {
     if (!state.selectedDirectory || state.files.length === 0) return;
     
     try {
-        const options = {
-            sourceDir: state.selectedDirectory,
-            maxConcurrency: state.maxConcurrency,
-            includeSubdirs: state.includeSubdirs
-        };
+        const options = buildOptions();
         
         await ProcessFiles(options);
         
@@ -327,6 +794,10 @@ function displayResults(results: any) {
                 <span class="label">Errors:</span>
                 <span class="value ${results.errorCount > 0 ? 'error' : 'success'}">${results.errorCount}</span>
             </div>
+            <div class="result-item">
+                <span class="label">Skipped (unchanged):</span>
+                <span class="value">${results.skippedCount || 0}</span>
+            </div>
             <div class="result-item">
                 <span class="label">Processed size:</span>
                 <span class="value">${sizeText}</span>
@@ -360,9 +831,54 @@ function displayResults(results: any) {
         `;
     }
     
+    if (results.missingMedia && results.missingMedia.length > 0) {
+        html += `
+            <div class="errors-section">
+                <h3>🖼️ Missing Media (${results.missingMedia.length})</h3>
+                <div class="error-list">
+        `;
+        
+        results.missingMedia.forEach((missing: any) => {
+            html += `
+                <div class="error-item">
+                    <div class="error-file">${missing.filePath}</div>
+                    <div class="error-message">${missing.mediaPath}</div>
+                </div>
+            `;
+        });
+        
+        html += `
+                </div>
+            </div>
+        `;
+    }
+    
     resultsContainer.innerHTML = html;
 }
 
+function displayFileList(files: any[]) {
+    const kindLabels: { [kind: string]: string } = {
+        chat: '💬 Chat export',
+        account: '👤 Full account export',
+        unknown: '⛔ Not a Telegram export'
+    };
+
+    let html = '';
+    files.forEach((file: any) => {
+        const archive = file.archivePath ? `📦 ${file.archivePath.split(/[\\/]/).pop()}` : '';
+        const details = [file.chatName, file.chatType, archive, file.errorMessage].filter(Boolean).join(' · ');
+        html += `
+            <div class="file-item ${file.kind === 'unknown' ? 'skipped' : ''}">
+                <span class="file-name">${file.name}</span>
+                <span class="file-kind">${kindLabels[file.kind] || file.kind}</span>
+                ${details ? `<span class="file-details">${details}</span>` : ''}
+            </div>
+        `;
+    });
+
+    fileListDiv.innerHTML = html;
+}
+
 function formatFileSize(bytes: number): string {
     if (bytes === 0) return '0 B';
     
@@ -10,6 +10,10 @@ export function IsProcessing():Promise<boolean>;
 
 export function ProcessFiles(arg1:models.ProcessOptions):Promise<void>;
 
-export function ScanDirectory(arg1:string,arg2:boolean):Promise<Array<models.FileInfo>>;
+export function ScanDirectory(arg1:models.ProcessOptions):Promise<Array<models.FileInfo>>;
 
 export function SelectDirectory():Promise<string>;
+
+export function SelectOutputDirectory():Promise<string>;
+
+export function SelectTemplateFile():Promise<string>;
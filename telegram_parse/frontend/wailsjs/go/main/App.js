@@ -18,10 +18,18 @@ export function ProcessFiles(arg1) {
   return window['go']['main']['App']['ProcessFiles'](arg1);
 }
 
-export function ScanDirectory(arg1, arg2) {
-  return window['go']['main']['App']['ScanDirectory'](arg1, arg2);
+export function ScanDirectory(arg1) {
+  return window['go']['main']['App']['ScanDirectory'](arg1);
 }
 
 export function SelectDirectory() {
   return window['go']['main']['App']['SelectDirectory']();
 }
+
+export function SelectOutputDirectory() {
+  return window['go']['main']['App']['SelectOutputDirectory']();
+}
+
+export function SelectTemplateFile() {
+  return window['go']['main']['App']['SelectTemplateFile']();
+}
@@ -8,6 +8,11 @@ export namespace models {
 	    modTime: any;
 	    status: string;
 	    errorMessage?: string;
+	    kind: string;
+	    chatName?: string;
+	    chatType?: string;
+	    archivePath?: string;
+	    entryName?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new FileInfo(source);
@@ -21,6 +26,11 @@ export namespace models {
 	        this.modTime = this.convertValues(source["modTime"], null);
 	        this.status = source["status"];
 	        this.errorMessage = source["errorMessage"];
+	        this.kind = source["kind"];
+	        this.chatName = source["chatName"];
+	        this.chatType = source["chatType"];
+	        this.archivePath = source["archivePath"];
+	        this.entryName = source["entryName"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -43,8 +53,29 @@ export namespace models {
 	}
 	export class ProcessOptions {
 	    sourceDir: string;
+	    outputDir: string;
 	    maxConcurrency: number;
 	    includeSubdirs: boolean;
+	    incremental: boolean;
+	    includePatterns: string[];
+	    excludePatterns: string[];
+	    maxDepth: number;
+	    followSymlinks: boolean;
+	    skipHidden: boolean;
+	    mediaMode: string;
+	    embedImages: boolean;
+	    inlineImageMaxKB: number;
+	    mediaInventory: boolean;
+	    topicMode: string;
+	    layout: string;
+	    outputFormat: string;
+	    sitePages: string;
+	    csvBOM: boolean;
+	    markdownFlavor: string;
+	    templatePath: string;
+	    language: string;
+	    headerStyle: string;
+	    headerFields: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new ProcessOptions(source);
@@ -53,8 +84,29 @@ export namespace models {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.sourceDir = source["sourceDir"];
+	        this.outputDir = source["outputDir"];
 	        this.maxConcurrency = source["maxConcurrency"];
 	        this.includeSubdirs = source["includeSubdirs"];
+	        this.incremental = source["incremental"];
+	        this.includePatterns = source["includePatterns"];
+	        this.excludePatterns = source["excludePatterns"];
+	        this.maxDepth = source["maxDepth"];
+	        this.followSymlinks = source["followSymlinks"];
+	        this.skipHidden = source["skipHidden"];
+	        this.mediaMode = source["mediaMode"];
+	        this.embedImages = source["embedImages"];
+	        this.inlineImageMaxKB = source["inlineImageMaxKB"];
+	        this.mediaInventory = source["mediaInventory"];
+	        this.topicMode = source["topicMode"];
+	        this.layout = source["layout"];
+	        this.outputFormat = source["outputFormat"];
+	        this.sitePages = source["sitePages"];
+	        this.csvBOM = source["csvBOM"];
+	        this.markdownFlavor = source["markdownFlavor"];
+	        this.templatePath = source["templatePath"];
+	        this.language = source["language"];
+	        this.headerStyle = source["headerStyle"];
+	        this.headerFields = source["headerFields"];
 	    }
 	}
 	export class Progress {
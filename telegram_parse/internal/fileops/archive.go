@@ -0,0 +1,103 @@
+package fileops
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"telegram_parse/internal/models"
+)
+
+// isZipFile checks if file has ZIP extension
+func (s *Scanner) isZipFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".zip"
+}
+
+// ScanArchive lists JSON files stored inside a ZIP archive
+func (s *Scanner) ScanArchive(archivePath string) ([]models.FileInfo, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer reader.Close()
+
+	var files []models.FileInfo
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() || !s.isJSONFile(entry.Name) {
+			continue
+		}
+
+		classification := Classification{Kind: KindNotExport}
+		if rc, err := entry.Open(); err == nil {
+			classification = classifyReader(rc)
+			rc.Close()
+		}
+
+		files = append(files, models.FileInfo{
+			Path:        filepath.Join(archivePath, filepath.FromSlash(entry.Name)),
+			Name:        path.Base(entry.Name),
+			Size:        int64(entry.UncompressedSize64),
+			ModTime:     entry.Modified,
+			Status:      "pending",
+			Kind:        classification.Kind,
+			ChatName:    classification.ChatName,
+			ChatType:    classification.ChatType,
+			ArchivePath: archivePath,
+			EntryName:   entry.Name,
+		})
+	}
+
+	return files, nil
+}
+
+// OpenSource opens a scanned file for reading, either from disk or from
+// inside a ZIP archive
+func OpenSource(file models.FileInfo) (io.ReadCloser, error) {
+	fsys, name, closer, err := SourceFS(file)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := fsys.Open(name)
+	if err != nil {
+		closer.Close()
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+
+	return &sourceReader{ReadCloser: rc, closer: closer}, nil
+}
+
+// SourceFS returns a file system containing the scanned file and the name of
+// the file within it. Media files referenced by the export are resolved
+// relative to the same file system. The returned closer must be closed when done.
+func SourceFS(file models.FileInfo) (fs.FS, string, io.Closer, error) {
+	if file.ArchivePath == "" {
+		return os.DirFS(filepath.Dir(file.Path)), filepath.Base(file.Path), io.NopCloser(nil), nil
+	}
+
+	reader, err := zip.OpenReader(file.ArchivePath)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	return reader, file.EntryName, reader, nil
+}
+
+// sourceReader closes both the opened file and its containing archive
+type sourceReader struct {
+	io.ReadCloser
+	closer io.Closer
+}
+
+// Close closes the file and the archive
+func (r *sourceReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.closer.Close()
+	return err
+}
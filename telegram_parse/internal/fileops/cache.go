@@ -0,0 +1,162 @@
+package fileops
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"telegram_parse/internal/models"
+)
+
+// CacheFileName is the name of the incremental conversion cache file
+const CacheFileName = ".telegram_parse_cache.json"
+
+// CacheEntry describes a previously converted source file
+type CacheEntry struct {
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"modTime"`
+	Hash        string    `json:"hash"`
+	Fingerprint string    `json:"fingerprint"`
+	OutputPath  string    `json:"outputPath"`
+}
+
+// Cache keeps track of converted files between runs
+type Cache struct {
+	path    string
+	mu      sync.Mutex
+	Entries map[string]CacheEntry `json:"entries"`
+}
+
+// LoadCache loads the conversion cache stored in the given directory.
+// A missing or unreadable cache results in an empty cache.
+func LoadCache(dirPath string) *Cache {
+	cache := &Cache{
+		path:    filepath.Join(dirPath, CacheFileName),
+		Entries: make(map[string]CacheEntry),
+	}
+
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache
+	}
+
+	// Start over if the cache is corrupted
+	if err := json.Unmarshal(data, cache); err != nil || cache.Entries == nil {
+		cache.Entries = make(map[string]CacheEntry)
+	}
+
+	return cache
+}
+
+// Unchanged reports whether the file was already converted with the same
+// options and neither the source nor the output changed since then.
+// Size and modification time are checked first; the content hash is only
+// computed when they differ, so touched but identical files are still skipped.
+func (c *Cache) Unchanged(file models.FileInfo, fingerprint string) bool {
+	c.mu.Lock()
+	entry, ok := c.Entries[file.Path]
+	c.mu.Unlock()
+
+	if !ok || entry.Fingerprint != fingerprint {
+		return false
+	}
+
+	if _, err := os.Stat(entry.OutputPath); err != nil {
+		return false
+	}
+
+	if entry.Size == file.Size && entry.ModTime.Equal(file.ModTime) {
+		return true
+	}
+
+	hash, err := HashFile(file)
+	if err != nil || hash != entry.Hash {
+		return false
+	}
+
+	// Content is the same, remember the new size and modification time
+	c.Record(file, fingerprint, hash, entry.OutputPath)
+	return true
+}
+
+// Record stores the conversion state of a file
+func (c *Cache) Record(file models.FileInfo, fingerprint, hash, outputPath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Entries[file.Path] = CacheEntry{
+		Size:        file.Size,
+		ModTime:     file.ModTime,
+		Hash:        hash,
+		Fingerprint: fingerprint,
+		OutputPath:  outputPath,
+	}
+}
+
+// Save writes the cache back to disk
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	err = os.WriteFile(c.path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	return nil
+}
+
+// HashFile returns the SHA-256 hash of file contents
+func HashFile(file models.FileInfo) (string, error) {
+	source, err := OpenSource(file)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, source); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// OptionsFingerprint returns a hash of the options that affect conversion
+// output. Options that only control scheduling or file discovery are ignored.
+// Template output also depends on the contents of the template file.
+func OptionsFingerprint(options models.ProcessOptions) string {
+	options.SourceDir = ""
+	options.MaxConcurrency = 0
+	options.IncludeSubdirs = false
+	options.Incremental = false
+	options.IncludePatterns = nil
+	options.ExcludePatterns = nil
+	options.MaxDepth = 0
+	options.FollowSymlinks = false
+	options.SkipHidden = false
+
+	data, _ := json.Marshal(options)
+	hash := sha256.New()
+	hash.Write(data)
+
+	// An unreadable template fails the conversion, so it is not cached anyway
+	if options.OutputFormat == "template" && options.TemplatePath != "" {
+		if template, err := os.ReadFile(options.TemplatePath); err == nil {
+			hash.Write(template)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
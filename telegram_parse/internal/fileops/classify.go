@@ -0,0 +1,131 @@
+package fileops
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+)
+
+// Export kinds stored in FileInfo.Kind
+const (
+	KindChatExport    = "chat"    // Single chat export with a messages list
+	KindAccountExport = "account" // Full account export (result.json with all chats)
+	KindNotExport     = "unknown" // Any other JSON file
+)
+
+// sniffLimit is the number of bytes read to classify a file
+const sniffLimit = 64 * 1024
+
+// chatTypes lists chat types used in Telegram exports
+var chatTypes = map[string]bool{
+	"personal_chat":      true,
+	"bot_chat":           true,
+	"saved_messages":     true,
+	"private_group":      true,
+	"private_supergroup": true,
+	"public_supergroup":  true,
+	"private_channel":    true,
+	"public_channel":     true,
+}
+
+// Classification describes what kind of Telegram export a JSON file is
+type Classification struct {
+	Kind     string
+	ChatName string
+	ChatType string
+}
+
+// ClassifyFile sniffs the beginning of a JSON file to detect Telegram exports
+func (s *Scanner) ClassifyFile(path string) Classification {
+	file, err := os.Open(path)
+	if err != nil {
+		return Classification{Kind: KindNotExport}
+	}
+	defer file.Close()
+
+	return classifyReader(file)
+}
+
+// classifyReader reads top-level keys of a JSON object until the export kind
+// is known. Only the first sniffLimit bytes are read.
+func classifyReader(r io.Reader) Classification {
+	result := Classification{Kind: KindNotExport}
+	decoder := json.NewDecoder(io.LimitReader(r, sniffLimit))
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return result
+	}
+
+	// Chat exports write the chat type and ID before the messages
+	hasType, hasID := false, false
+
+keys:
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			break
+		}
+
+		switch key {
+		case "name":
+			var name string
+			if decoder.Decode(&name) != nil {
+				break keys
+			}
+			result.ChatName = name
+		case "type":
+			var chatType string
+			if decoder.Decode(&chatType) != nil {
+				break keys
+			}
+			result.ChatType = chatType
+			hasType = true
+		case "id":
+			var id json.Number
+			if decoder.Decode(&id) != nil {
+				break keys
+			}
+			hasID = true
+		case "messages":
+			// Messages list may be larger than the sniff limit, check the opening bracket only
+			if token, err := decoder.Token(); err == nil && token == json.Delim('[') && hasType && hasID {
+				result.Kind = KindChatExport
+			}
+			return result
+		case "personal_information":
+			var info struct {
+				FirstName string `json:"first_name"`
+				LastName  string `json:"last_name"`
+			}
+			if decoder.Decode(&info) == nil {
+				result.ChatName = strings.TrimSpace(info.FirstName + " " + info.LastName)
+			}
+			result.Kind = KindAccountExport
+			result.ChatType = ""
+			return result
+		case "chats", "left_chats":
+			result.Kind = KindAccountExport
+			result.ChatType = ""
+			return result
+		default:
+			var skipped json.RawMessage
+			if decoder.Decode(&skipped) != nil {
+				break keys
+			}
+		}
+	}
+
+	// Sniff limit reached before the messages list, rely on the chat type
+	if chatTypes[result.ChatType] {
+		result.Kind = KindChatExport
+		return result
+	}
+
+	return Classification{Kind: KindNotExport}
+}
@@ -0,0 +1,178 @@
+package fileops
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ScanOptions controls which files are picked up by the scanner
+type ScanOptions struct {
+	IncludeSubdirs  bool
+	IncludePatterns []string // Files must match at least one pattern, if any
+	ExcludePatterns []string // Matching files and directories are skipped
+	MaxDepth        int      // Maximum subdirectory depth, 0 for unlimited
+	FollowSymlinks  bool     // Descend into symbolic links to directories
+	SkipHidden      bool     // Skip directories starting with a dot
+}
+
+// scanFilter matches relative slash-separated paths against glob patterns.
+//
+// Patterns follow .gitignore conventions: a pattern without a slash matches
+// the name at any depth ("*.bak"), a pattern with a slash is anchored to the
+// scanned directory ("backup/old/*.json"), a trailing slash restricts it to
+// directories ("media/"). "*" and "?" do not cross directory boundaries,
+// "**" matches any number of directories.
+type scanFilter struct {
+	include []globPattern
+	exclude []globPattern
+}
+
+// globPattern is a compiled scan pattern
+type globPattern struct {
+	re      *regexp.Regexp
+	dirOnly bool
+}
+
+// newScanFilter compiles include and exclude patterns
+func newScanFilter(options ScanOptions) (*scanFilter, error) {
+	filter := &scanFilter{}
+
+	for _, pattern := range options.IncludePatterns {
+		compiled, ok, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filter.include = append(filter.include, compiled)
+		}
+	}
+
+	for _, pattern := range options.ExcludePatterns {
+		compiled, ok, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filter.exclude = append(filter.exclude, compiled)
+		}
+	}
+
+	return filter, nil
+}
+
+// excludedDir reports whether a directory is excluded
+func (f *scanFilter) excludedDir(rel string) bool {
+	for _, pattern := range f.exclude {
+		if pattern.re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedFile reports whether a file is excluded by a file pattern
+func (f *scanFilter) excludedFile(rel string) bool {
+	for _, pattern := range f.exclude {
+		if !pattern.dirOnly && pattern.re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsFile reports whether a file passes include and exclude patterns.
+// Parent directories are checked too, so directory patterns also apply
+// to paths inside ZIP archives.
+func (f *scanFilter) allowsFile(rel string) bool {
+	if f.excludedFile(rel) {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if f.excludedDir(strings.Join(parts[:i], "/")) {
+			return false
+		}
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+
+	for _, pattern := range f.include {
+		if !pattern.dirOnly && pattern.re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileGlob converts a glob pattern to a regular expression.
+// Empty patterns are ignored.
+func compileGlob(pattern string) (globPattern, bool, error) {
+	pattern = strings.TrimSpace(strings.ReplaceAll(pattern, "\\", "/"))
+	if pattern == "" {
+		return globPattern{}, false, nil
+	}
+
+	compiled := globPattern{}
+	if strings.HasSuffix(pattern, "/") {
+		compiled.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+
+	// Patterns without a slash match at any depth
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" matches zero or more directories
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					expr.WriteString("(?:.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return globPattern{}, false, fmt.Errorf("invalid pattern %q: unclosed character class", pattern)
+			}
+			// Negated classes do not cross directory boundaries either
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^/" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return globPattern{}, false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	compiled.re = re
+	return compiled, true, nil
+}
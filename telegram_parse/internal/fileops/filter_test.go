@@ -0,0 +1,64 @@
+package fileops
+
+import "testing"
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		dir     bool
+		want    bool
+	}{
+		// Directory patterns match directories at any depth
+		{"media/", "media", true, true},
+		{"media/", "chat/media", true, true},
+		{"media/", "media", false, false},
+		{"media/", "media2", true, false},
+
+		// "**" matches any number of directories
+		{"backup/**", "backup/result.json", false, true},
+		{"backup/**", "backup/old/result.json", false, true},
+		{"backup/**", "chat/backup/result.json", false, false},
+
+		// "**/" matches zero or more directories
+		{"**/stickers/", "stickers", true, true},
+		{"**/stickers/", "chat/stickers", true, true},
+		{"**/stickers/", "a/b/stickers", true, true},
+		{"**/stickers/", "chat/stickers", false, false},
+
+		// A leading slash anchors the pattern to the scanned directory
+		{"/anchored/*.json", "anchored/result.json", false, true},
+		{"/anchored/*.json", "chat/anchored/result.json", false, false},
+		{"/anchored/*.json", "anchored/sub/result.json", false, false},
+
+		// Negated character classes
+		{"[!a]*.json", "result.json", false, true},
+		{"[!a]*.json", "archive.json", false, false},
+		{"[!a]*.json", "chat/result.json", false, true},
+		{"[!a]*.json", "chat/archive.json", false, false},
+		{"a[!b]c", "axc", false, true},
+		{"a[!b]c", "a/c", false, false},
+	}
+
+	for _, tt := range tests {
+		compiled, ok, err := compileGlob(tt.pattern)
+		if err != nil || !ok {
+			t.Fatalf("compileGlob(%q) = %v, %v", tt.pattern, ok, err)
+		}
+
+		got := compiled.re.MatchString(tt.path) && (tt.dir || !compiled.dirOnly)
+		if got != tt.want {
+			t.Errorf("%q matches %q (dir %v) = %v, want %v", tt.pattern, tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestCompileGlobInvalid(t *testing.T) {
+	if _, ok, err := compileGlob("  "); ok || err != nil {
+		t.Errorf("empty pattern = %v, %v, want ignored", ok, err)
+	}
+
+	if _, _, err := compileGlob("[abc.json"); err == nil {
+		t.Error("unclosed character class compiled without error")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -18,46 +19,144 @@ func NewScanner() *Scanner {
 	return &Scanner{}
 }
 
-// ScanDirectory scans directory for JSON files
+// ScanDirectory scans directory for JSON files, including JSON files
+// stored in ZIP archives
 func (s *Scanner) ScanDirectory(dirPath string, includeSubdirs bool) ([]models.FileInfo, error) {
+	return s.ScanDirectoryWithOptions(dirPath, ScanOptions{IncludeSubdirs: includeSubdirs})
+}
+
+// ScanDirectoryWithOptions scans directory for JSON files and ZIP archives
+// applying filtering options
+func (s *Scanner) ScanDirectoryWithOptions(dirPath string, options ScanOptions) ([]models.FileInfo, error) {
+	filter, err := newScanFilter(options)
+	if err != nil {
+		return nil, err
+	}
+
 	var files []models.FileInfo
+	visited := make(map[string]bool)
+
+	err = s.walkDirectory(dirPath, dirPath, 0, options, filter, visited, &files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	return files, nil
+}
+
+// walkDirectory collects files from a directory and its subdirectories.
+// Unlike filepath.WalkDir it can follow symbolic links to directories;
+// visited real paths are tracked to avoid cycles.
+func (s *Scanner) walkDirectory(root, dir string, depth int, options ScanOptions, filter *scanFilter, visited map[string]bool, files *[]models.FileInfo) error {
+	if realPath, err := filepath.EvalSymlinks(dir); err == nil {
+		if visited[realPath] {
+			return nil
+		}
+		visited[realPath] = true
+	}
 
-	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		rel, err := filepath.Rel(root, path)
 		if err != nil {
 			return err
 		}
+		rel = filepath.ToSlash(rel)
 
-		// Skip subdirectories if not requested
-		if !includeSubdirs && path != dirPath && d.IsDir() {
-			return filepath.SkipDir
+		isDir := entry.IsDir()
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// Resolve link target, broken links are ignored
+			target, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if target.IsDir() && !options.FollowSymlinks {
+				continue
+			}
+			isDir = target.IsDir()
 		}
 
-		// Process only JSON files
-		if !d.IsDir() && s.isJSONFile(path) {
-			info, err := d.Info()
+		if isDir {
+			// Skip subdirectories if not requested or too deep
+			if !options.IncludeSubdirs || (options.MaxDepth > 0 && depth >= options.MaxDepth) {
+				continue
+			}
+
+			if options.SkipHidden && strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			if filter.excludedDir(rel) {
+				continue
+			}
+
+			err := s.walkDirectory(root, path, depth+1, options, filter, visited, files)
 			if err != nil {
 				return err
 			}
+			continue
+		}
 
-			fileInfo := models.FileInfo{
-				Path:    path,
-				Name:    d.Name(),
-				Size:    info.Size(),
-				ModTime: info.ModTime(),
-				Status:  "pending",
+		// Enumerate JSON files inside ZIP archives
+		if s.isZipFile(path) {
+			if filter.excludedFile(rel) {
+				continue
 			}
 
-			files = append(files, fileInfo)
+			// Unreadable archives are listed with their error instead of
+			// stopping the scan
+			archived, err := s.ScanArchive(path)
+			if err != nil {
+				*files = append(*files, models.FileInfo{
+					Path:         path,
+					Name:         entry.Name(),
+					Status:       "error",
+					ErrorMessage: err.Error(),
+					Kind:         KindNotExport,
+				})
+				continue
+			}
+
+			for _, fileInfo := range archived {
+				if filter.allowsFile(rel + "/" + fileInfo.EntryName) {
+					*files = append(*files, fileInfo)
+				}
+			}
+			continue
 		}
 
-		return nil
-	})
+		// Process only JSON files, ignoring our own conversion cache
+		if !s.isJSONFile(path) || entry.Name() == CacheFileName || !filter.allowsFile(rel) {
+			continue
+		}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to scan directory: %w", err)
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		classification := s.ClassifyFile(path)
+
+		fileInfo := models.FileInfo{
+			Path:     path,
+			Name:     entry.Name(),
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+			Status:   "pending",
+			Kind:     classification.Kind,
+			ChatName: classification.ChatName,
+			ChatType: classification.ChatType,
+		}
+
+		*files = append(*files, fileInfo)
 	}
 
-	return files, nil
+	return nil
 }
 
 // isJSONFile checks if file has JSON extension
@@ -92,11 +191,33 @@ func (s *Scanner) GetFileSize(path string) (int64, error) {
 	return info.Size(), nil
 }
 
-// CreateOutputPath creates output markdown file path
-func (s *Scanner) CreateOutputPath(jsonPath string) string {
-	dir := filepath.Dir(jsonPath)
-	name := strings.TrimSuffix(filepath.Base(jsonPath), filepath.Ext(jsonPath))
-	return filepath.Join(dir, name+".md")
+// CreateOutputPath creates output file path with the given extension. Outputs
+// are written next to the source file (or archive) unless outputDir is set, in
+// which case the directory structure relative to sourceDir is recreated there.
+func (s *Scanner) CreateOutputPath(file models.FileInfo, sourceDir, outputDir, ext string) string {
+	sourcePath := file.Path
+	if file.ArchivePath != "" {
+		sourcePath = file.ArchivePath
+	}
+
+	dir := filepath.Dir(sourcePath)
+	name := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
+
+	// Archive entries are named after the archive and the entry path
+	if file.ArchivePath != "" {
+		entry := strings.TrimSuffix(file.EntryName, path.Ext(file.EntryName))
+		name += "_" + strings.ReplaceAll(entry, "/", "_")
+	}
+
+	if outputDir != "" {
+		rel, err := filepath.Rel(sourceDir, dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = ""
+		}
+		dir = filepath.Join(outputDir, rel)
+	}
+
+	return filepath.Join(dir, name+ext)
 }
 
 // CheckDiskSpace checks if there's enough disk space for processing
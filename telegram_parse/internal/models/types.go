@@ -8,8 +8,13 @@ type FileInfo struct {
 	Name         string    `json:"name"`
 	Size         int64     `json:"size"`
 	ModTime      time.Time `json:"modTime"`
-	Status       string    `json:"status"` // "pending", "processing", "completed", "error"
+	Status       string    `json:"status"` // "pending", "processing", "completed", "skipped", "error"
 	ErrorMessage string    `json:"errorMessage,omitempty"`
+	Kind         string    `json:"kind"` // "chat", "account", "unknown"
+	ChatName     string    `json:"chatName,omitempty"`
+	ChatType     string    `json:"chatType,omitempty"`
+	ArchivePath  string    `json:"archivePath,omitempty"` // ZIP archive containing the file
+	EntryName    string    `json:"entryName,omitempty"`   // Path of the file inside the archive
 }
 
 // Progress represents the current processing progress
@@ -25,13 +30,16 @@ type Progress struct {
 
 // ProcessResult represents the result of processing operation
 type ProcessResult struct {
-	Success       bool          `json:"success"`
-	TotalFiles    int           `json:"totalFiles"`
-	SuccessCount  int           `json:"successCount"`
-	ErrorCount    int           `json:"errorCount"`
-	ProcessedSize int64         `json:"processedSize"`
-	Duration      time.Duration `json:"duration"`
-	Errors        []FileError   `json:"errors,omitempty"`
+	Success       bool           `json:"success"`
+	TotalFiles    int            `json:"totalFiles"`
+	SuccessCount  int            `json:"successCount"`
+	ErrorCount    int            `json:"errorCount"`
+	SkippedCount  int            `json:"skippedCount"`
+	ProcessedSize int64          `json:"processedSize"`
+	Duration      time.Duration  `json:"duration"`
+	Errors        []FileError    `json:"errors,omitempty"`
+	SkippedFiles  []string       `json:"skippedFiles,omitempty"`
+	MissingMedia  []MissingMedia `json:"missingMedia,omitempty"`
 }
 
 // FileError represents an error that occurred during file processing
@@ -40,9 +48,40 @@ type FileError struct {
 	Error    string `json:"error"`
 }
 
+// MissingMedia represents a media file referenced by an export but not found
+type MissingMedia struct {
+	FilePath  string `json:"filePath"`
+	MediaPath string `json:"mediaPath"`
+}
+
 // ProcessOptions represents options for processing operation
 type ProcessOptions struct {
 	SourceDir      string `json:"sourceDir"`
+	OutputDir      string `json:"outputDir"` // Empty to write outputs next to the sources
 	MaxConcurrency int    `json:"maxConcurrency"`
 	IncludeSubdirs bool   `json:"includeSubdirs"`
+	Incremental    bool   `json:"incremental"` // Skip files unchanged since the previous run
+
+	// Directory scanning filters
+	IncludePatterns []string `json:"includePatterns"` // Glob patterns of files to process
+	ExcludePatterns []string `json:"excludePatterns"` // Glob patterns of files and directories to skip
+	MaxDepth        int      `json:"maxDepth"`        // Maximum subdirectory depth, 0 for unlimited
+	FollowSymlinks  bool     `json:"followSymlinks"`
+	SkipHidden      bool     `json:"skipHidden"` // Skip directories starting with a dot
+
+	// Output options
+	MediaMode        string   `json:"mediaMode"`        // "reference", "copy", "hardlink"
+	EmbedImages      bool     `json:"embedImages"`      // Render photos and stickers as images
+	InlineImageMaxKB int      `json:"inlineImageMaxKB"` // Inline images up to this size as data URIs, 0 to disable
+	MediaInventory   bool     `json:"mediaInventory"`   // Add media inventory appendix and CSV
+	TopicMode        string   `json:"topicMode"`        // "chronological", "sections", "files"
+	Layout           string   `json:"layout"`           // "chronological", "threaded", "channel"
+	OutputFormat     string   `json:"outputFormat"`     // "markdown", "obsidian", "hugo", "jekyll", "jsonl", "csv", "sqlite", "epub", "org", "asciidoc", "text", "template"
+	SitePages        string   `json:"sitePages"`        // Static site page per "post", "day" or "month"
+	CSVBOM           bool     `json:"csvBOM"`           // Start CSV files with a UTF-8 BOM for Excel
+	MarkdownFlavor   string   `json:"markdownFlavor"`   // "commonmark", "gfm", "telegram", "discord"
+	TemplatePath     string   `json:"templatePath"`     // Go template file of template output
+	Language         string   `json:"language"`         // Language of labels and dates, "en" or "ru"
+	HeaderStyle      string   `json:"headerStyle"`      // "markdown", "yaml", "toml" or "none"
+	HeaderFields     []string `json:"headerFields"`     // Optional header fields: "dates", "participants", "source", "generated", "version"
 }
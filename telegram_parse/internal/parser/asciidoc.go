@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// asciidocSyntax renders AsciiDoc documents. Messages are sections with
+// their metadata in block attributes.
+type asciidocSyntax struct{}
+
+// document returns the document title and header attributes
+func (asciidocSyntax) document(title string) string {
+	return fmt.Sprintf("= %s\n:toc:\n:sectanchors:\n\n", propertyValue(title))
+}
+
+// heading returns a section title preceded by its ID and attributes
+func (asciidocSyntax) heading(level int, title, anchor string, properties []markupProperty) string {
+	var attributes []string
+	if anchor != "" {
+		attributes = append(attributes, "#"+anchor)
+	}
+	for _, property := range properties {
+		key := strings.ReplaceAll(property.Key, "_", "-")
+		value := strings.ReplaceAll(propertyValue(property.Value), "\"", "\\\"")
+		attributes = append(attributes, fmt.Sprintf("%s=\"%s\"", key, value))
+	}
+
+	var result strings.Builder
+	if len(attributes) > 0 {
+		result.WriteString("[" + strings.Join(attributes, ",") + "]\n")
+	}
+	result.WriteString(strings.Repeat("=", level+1) + " " + propertyValue(title) + "\n\n")
+	return result.String()
+}
+
+// asciidocInlineMarks start inline formatting, passthroughs, attribute
+// references, macros and cross references
+const asciidocInlineMarks = "*_#`^~{+[<"
+
+// text keeps line breaks and stops lines from being read as block syntax or
+// inline formatting
+func (asciidocSyntax) text(text string) string {
+	lines := strings.Split(text, "\n")
+
+	var result strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			// Hard line breaks only between lines of the same paragraph
+			if line != "" && lines[i-1] != "" {
+				result.WriteString(" +")
+			}
+			result.WriteString("\n")
+		}
+		if line != "" && strings.ContainsRune("=*-.[:/|+<>_", rune(line[0])) {
+			result.WriteString("{empty}")
+		}
+		result.WriteString(asciidocEscapeInline(line))
+	}
+	return result.String()
+}
+
+// asciidocEscapeInline wraps runs of inline markup characters in passthroughs
+// that only escape HTML. A backslash before them would escape the
+// passthrough, so it is written as an attribute reference.
+func asciidocEscapeInline(line string) string {
+	var result strings.Builder
+	for i := 0; i < len(line); {
+		end := i
+		for end < len(line) && strings.IndexByte(asciidocInlineMarks, line[end]) >= 0 {
+			end++
+		}
+		if end > i {
+			result.WriteString("pass:c[" + line[i:end] + "]")
+			i = end
+			continue
+		}
+
+		if line[i] == '\\' && i+1 < len(line) && strings.IndexByte(asciidocInlineMarks, line[i+1]) >= 0 {
+			result.WriteString("{backslash}")
+		} else {
+			result.WriteByte(line[i])
+		}
+		i++
+	}
+	return result.String()
+}
+
+// entity formats a text entity with AsciiDoc markup
+func (s asciidocSyntax) entity(text, entityType string, entity map[string]interface{}) string {
+	switch entityType {
+	case "bold":
+		return wrapMarker(text, "**", "**")
+	case "italic":
+		return wrapMarker(text, "__", "__")
+	case "code":
+		return wrapMarker(text, "`+", "+`")
+	case "pre":
+		language, _ := entity["language"].(string)
+		style := "[source]"
+		if language != "" {
+			style = fmt.Sprintf("[source,%s]", language)
+		}
+		return fmt.Sprintf("\n\n%s\n----\n%s\n----\n\n", style, strings.Trim(text, "\n"))
+	case "text_link":
+		if href, ok := entity["href"].(string); ok {
+			return fmt.Sprintf("link:%s[%s]", href, asciidocLinkLabel(text))
+		}
+		return s.text(text)
+	case "link", "email":
+		// URLs and addresses are linked automatically
+		return text
+	case "strikethrough":
+		return wrapMarker(text, "[.line-through]##", "##")
+	case "underline":
+		return wrapMarker(text, "[.underline]##", "##")
+	case "spoiler":
+		return wrapMarker(text, "[.spoiler]##", "##")
+	case "blockquote":
+		return fmt.Sprintf("\n\n____\n%s\n____\n\n", s.text(strings.Trim(text, "\n")))
+	default:
+		return s.text(text)
+	}
+}
+
+// messageLink returns a cross reference to the section of a message
+func (asciidocSyntax) messageLink(anchor, label string) string {
+	return fmt.Sprintf("<<%s,%s>>", anchor, strings.ReplaceAll(label, ">>", ">"))
+}
+
+// note returns italic text
+func (asciidocSyntax) note(text string) string {
+	return wrapMarker(text, "__", "__")
+}
+
+// image returns a block image
+func (asciidocSyntax) image(link string) string {
+	return fmt.Sprintf("image::%s[]", link)
+}
+
+// fileLink returns a link to a local file
+func (asciidocSyntax) fileLink(link, label string) string {
+	return fmt.Sprintf("link:%s[%s]", link, asciidocLinkLabel(label))
+}
+
+// asciidocLinkLabel escapes brackets that would end a link text
+func asciidocLinkLabel(label string) string {
+	return strings.ReplaceAll(label, "]", "\\]")
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"telegram_parse/internal/telegram"
+)
+
+// maxPostTitleLength limits post titles taken from the first line of text
+const maxPostTitleLength = 80
+
+// isChannel reports whether a chat type is a broadcast channel
+func isChannel(chatType string) bool {
+	return chatType == "public_channel" || chatType == "private_channel"
+}
+
+// writePosts converts channel posts to article-style sections
+func (p *JSONToMarkdown) writePosts(messages []*telegram.Message, result *strings.Builder) {
+	for _, message := range messages {
+		var postMarkdown string
+		if message.Type == "service" {
+			postMarkdown = p.messageToMarkdown(message)
+		} else {
+			postMarkdown = p.postToMarkdown(message)
+		}
+
+		if postMarkdown != "" {
+			result.WriteString(postMarkdown)
+			result.WriteString("\n")
+		}
+	}
+}
+
+// postToMarkdown converts a channel post to a section titled by its first
+// line, followed by date, author, views and reactions
+func (p *JSONToMarkdown) postToMarkdown(msg *telegram.Message) string {
+	var result strings.Builder
+
+	// A title that is the whole first line is not repeated in the body
+	title := p.postTitle(msg)
+	post := *msg
+	if text, ok := dropFirstLine(msg.Text, title); ok {
+		post.Text = text
+	}
+
+	result.WriteString(p.messageHeader(p.escapeText(title), msg.ID))
+	p.writePostBody(&post, &result)
+
+	result.WriteString("\n---\n\n")
+	return result.String()
+}
+
+// writePostBody writes post metadata, content and reactions
+func (p *JSONToMarkdown) writePostBody(msg *telegram.Message, result *strings.Builder) {
+	// Post metadata
+	var meta []string
+	if msg.Date != "" {
+		meta = append(meta, "📅 "+p.displayDate(msg.Date))
+	}
+	if msg.Author != "" {
+		meta = append(meta, "✍️ "+msg.Author)
+	}
+	if msg.Views > 0 {
+		meta = append(meta, "👁️ "+p.plural(msg.Views, "view"))
+	}
+	if msg.Edited != "" {
+		meta = append(meta, p.tr("edited %s", p.displayDate(msg.Edited)))
+	}
+	if len(meta) > 0 {
+		result.WriteString(fmt.Sprintf("*%s*\n\n", strings.Join(meta, " · ")))
+	}
+
+	p.processRegularMessage(msg, result)
+
+	if len(msg.InlineBotButtons) > 0 {
+		p.processButtons(msg.InlineBotButtons, result)
+	}
+
+	if len(msg.Reactions) > 0 {
+		p.processReactions(msg.Reactions, result)
+	}
+
+	if msg.ForwardedFrom != "" {
+		result.WriteString("\n*" + p.tr("Forwarded from: %s", msg.ForwardedFrom) + "*\n")
+	}
+
+	if msg.ReplyToMessageID != 0 {
+		result.WriteString("\n*" + p.flavorMessageLinks(p.tr("In reply to %s", p.messageLink(msg.ReplyToMessageID, p.tr("post")))) + "*\n")
+	}
+}
+
+// postTitle returns the first line of the post text, or its date if the post
+// has no text
+func (p *JSONToMarkdown) postTitle(msg *telegram.Message) string {
+	for _, line := range strings.Split(plainText(msg.Text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		runes := []rune(line)
+		if len(runes) > maxPostTitleLength {
+			line = strings.TrimSpace(string(runes[:maxPostTitleLength])) + "…"
+		}
+		return line
+	}
+
+	if msg.Date != "" {
+		return p.tr("Post of %s", p.displayDate(msg.Date))
+	}
+	return p.tr("Post %d", msg.ID)
+}
+
+// dropFirstLine removes the first non-empty line of message text, keeping the
+// formatting of the rest. Returns false if that line differs from the given
+// one.
+func dropFirstLine(text interface{}, line string) (interface{}, bool) {
+	plain := plainText(text)
+	trimmed := strings.TrimLeftFunc(plain, unicode.IsSpace)
+	first, _, _ := strings.Cut(trimmed, "\n")
+	if strings.TrimSpace(first) != line {
+		return text, false
+	}
+
+	// Bytes to drop, including the line break
+	skip := len(plain) - len(trimmed) + len(first) + 1
+	if skip > len(plain) {
+		skip = len(plain)
+	}
+
+	switch t := text.(type) {
+	case string:
+		return t[skip:], true
+	case []interface{}:
+		var parts []interface{}
+		for _, item := range t {
+			switch v := item.(type) {
+			case string:
+				if skip >= len(v) {
+					skip -= len(v)
+					continue
+				}
+				parts = append(parts, v[skip:])
+				skip = 0
+			case map[string]interface{}:
+				entityText, _ := v["text"].(string)
+				if skip >= len(entityText) {
+					skip -= len(entityText)
+					continue
+				}
+				if skip > 0 {
+					entity := make(map[string]interface{}, len(v))
+					for key, value := range v {
+						entity[key] = value
+					}
+					entity["text"] = entityText[skip:]
+					v = entity
+				}
+				parts = append(parts, v)
+				skip = 0
+			}
+		}
+		return parts, true
+	default:
+		return text, false
+	}
+}
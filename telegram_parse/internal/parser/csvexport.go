@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"telegram_parse/internal/telegram"
+)
+
+// csvDirSuffix is appended to the output name of the CSV directory
+const csvDirSuffix = "_csv"
+
+// utf8BOM marks CSV files as UTF-8 for Excel
+const utf8BOM = "\ufeff"
+
+// csvFormulaPrefixes start cells that spreadsheets run as formulas
+const csvFormulaPrefixes = "=+-@"
+
+// participant aggregates messages of a chat member
+type participant struct {
+	FromID    string
+	Name      string
+	Messages  int
+	FirstSeen string
+	LastSeen  string
+}
+
+// writeCSV writes messages.csv and participants.csv for every chat into the
+// output directory, one subdirectory per chat for full account exports
+func (p *JSONToMarkdown) writeCSV(exports []telegram.Export, outputDir string) error {
+	for i := range exports {
+		dir := outputDir
+		if len(exports) > 1 {
+			dir = filepath.Join(outputDir, chatSlug(&exports[i]))
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+
+		if err := p.writeMessagesCSV(&exports[i], filepath.Join(dir, "messages.csv")); err != nil {
+			return err
+		}
+
+		if err := p.writeParticipantsCSV(&exports[i], filepath.Join(dir, "participants.csv")); err != nil {
+			return err
+		}
+
+		if p.mediaInventory {
+			p.conv.inventory = append(p.conv.inventory, p.collectMedia(&exports[i])...)
+		}
+	}
+
+	return nil
+}
+
+// writeMessagesCSV writes one row per message of a chat
+func (p *JSONToMarkdown) writeMessagesCSV(export *telegram.Export, csvPath string) error {
+	rows := [][]string{{"id", "date", "sender", "from_id", "type", "text", "media_type", "reply_to", "forwarded_from"}}
+
+	for i := range export.Messages {
+		msg := &export.Messages[i]
+
+		sender, text := msg.From, plainText(msg.Text)
+		if msg.Type == "service" {
+			// Links to pinned messages keep only their label
+			sender, text = msg.Actor, relinkMessages(p.describeAction(msg), func(_ int64, label string) string { return label })
+		}
+
+		mediaType := ""
+		if media := p.mediaRecord(msg); media != nil {
+			mediaType = media.Type
+		}
+
+		replyTo := ""
+		if msg.ReplyToMessageID != 0 {
+			replyTo = strconv.FormatInt(msg.ReplyToMessageID, 10)
+		}
+
+		rows = append(rows, []string{
+			strconv.FormatInt(msg.ID, 10),
+			p.csvDate(msg.Date),
+			sender,
+			msg.FromID,
+			msg.Type,
+			text,
+			mediaType,
+			replyTo,
+			msg.ForwardedFrom,
+		})
+	}
+
+	return p.writeCSVFile(csvPath, rows)
+}
+
+// writeParticipantsCSV writes message counts and activity of chat members
+func (p *JSONToMarkdown) writeParticipantsCSV(export *telegram.Export, csvPath string) error {
+	rows := [][]string{{"from_id", "name", "message_count", "first_seen", "last_seen"}}
+	for _, member := range p.chatParticipants(export) {
+		rows = append(rows, []string{
+			member.FromID,
+			member.Name,
+			strconv.Itoa(member.Messages),
+			member.FirstSeen,
+			member.LastSeen,
+		})
+	}
+
+	return p.writeCSVFile(csvPath, rows)
+}
+
+// chatParticipants aggregates the messages of chat members in order of their
+// first message
+func (p *JSONToMarkdown) chatParticipants(export *telegram.Export) []*participant {
+	var order []*participant
+	byKey := make(map[string]*participant)
+
+	for i := range export.Messages {
+		msg := &export.Messages[i]
+		if msg.Type == "service" || (msg.From == "" && msg.FromID == "") {
+			continue
+		}
+
+		key := msg.FromID
+		if key == "" {
+			key = msg.From
+		}
+
+		member, ok := byKey[key]
+		if !ok {
+			member = &participant{FromID: msg.FromID, FirstSeen: p.csvDate(msg.Date)}
+			byKey[key] = member
+			order = append(order, member)
+		}
+
+		// Names change over time, the latest one is kept
+		if msg.From != "" {
+			member.Name = msg.From
+		}
+		member.Messages++
+		member.LastSeen = p.csvDate(msg.Date)
+	}
+
+	return order
+}
+
+// csvDate formats a message date for spreadsheets
+func (p *JSONToMarkdown) csvDate(date string) string {
+	if date == "" {
+		return ""
+	}
+	return p.parseDate(date).Format("2006-01-02 15:04:05")
+}
+
+// writeCSVFile writes rows to a CSV file, optionally starting with a BOM
+func (p *JSONToMarkdown) writeCSVFile(csvPath string, rows [][]string) error {
+	file, err := os.Create(csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	if p.csvBOM {
+		if _, err := file.WriteString(utf8BOM); err != nil {
+			return fmt.Errorf("failed to write CSV file: %w", err)
+		}
+	}
+
+	writer := csv.NewWriter(file)
+	for _, row := range rows {
+		writer.Write(csvRow(row))
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+
+	return nil
+}
+
+// csvRow guards the cells of a row against formula evaluation
+func csvRow(row []string) []string {
+	cells := make([]string, len(row))
+	for i, value := range row {
+		cells[i] = csvCell(value)
+	}
+	return cells
+}
+
+// csvCell prefixes text that spreadsheets would run as a formula with an
+// apostrophe. Negative numbers are kept as they are.
+func csvCell(value string) string {
+	if value == "" || !strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
@@ -0,0 +1,298 @@
+package parser
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"telegram_parse/internal/telegram"
+
+	_ "modernc.org/sqlite"
+)
+
+// DatabaseFileName is the name of the SQLite database all chats of a run are
+// written to
+const DatabaseFileName = "telegram.db"
+
+// databaseSchema creates tables for chats and messages with a full-text
+// index over message text, kept in sync by triggers
+const databaseSchema = `
+CREATE TABLE IF NOT EXISTS chats (
+	id   INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	id   TEXT PRIMARY KEY,
+	name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+	rowid             INTEGER PRIMARY KEY,
+	chat_id           INTEGER NOT NULL REFERENCES chats(id),
+	id                INTEGER NOT NULL,
+	type              TEXT NOT NULL,
+	timestamp         TEXT NOT NULL,
+	edited            TEXT,
+	sender_id         TEXT,
+	sender_name       TEXT,
+	text              TEXT NOT NULL,
+	markdown          TEXT NOT NULL,
+	reply_to_id       INTEGER,
+	forwarded_from    TEXT,
+	forwarded_from_id TEXT,
+	saved_from        TEXT,
+	action            TEXT,
+	UNIQUE (chat_id, id)
+);
+
+CREATE INDEX IF NOT EXISTS messages_sender ON messages(sender_id);
+CREATE INDEX IF NOT EXISTS messages_timestamp ON messages(timestamp);
+
+CREATE TABLE IF NOT EXISTS entities (
+	chat_id    INTEGER NOT NULL,
+	message_id INTEGER NOT NULL,
+	position   INTEGER NOT NULL,
+	type       TEXT NOT NULL,
+	text       TEXT NOT NULL,
+	href       TEXT,
+	user_id    TEXT,
+	PRIMARY KEY (chat_id, message_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS media (
+	chat_id    INTEGER NOT NULL,
+	message_id INTEGER NOT NULL,
+	type       TEXT NOT NULL,
+	path       TEXT,
+	file_name  TEXT,
+	mime_type  TEXT,
+	size       INTEGER,
+	width      INTEGER,
+	height     INTEGER,
+	duration   INTEGER,
+	status     TEXT NOT NULL,
+	PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE TABLE IF NOT EXISTS reactions (
+	chat_id    INTEGER NOT NULL,
+	message_id INTEGER NOT NULL,
+	emoji      TEXT NOT NULL,
+	count      INTEGER NOT NULL,
+	PRIMARY KEY (chat_id, message_id, emoji)
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+	text, content='messages', content_rowid='rowid'
+);
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_insert AFTER INSERT ON messages BEGIN
+	INSERT INTO messages_fts(rowid, text) VALUES (new.rowid, new.text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_delete AFTER DELETE ON messages BEGIN
+	INSERT INTO messages_fts(messages_fts, rowid, text) VALUES ('delete', old.rowid, old.text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_update AFTER UPDATE OF text ON messages BEGIN
+	INSERT INTO messages_fts(messages_fts, rowid, text) VALUES ('delete', old.rowid, old.text);
+	INSERT INTO messages_fts(rowid, text) VALUES (new.rowid, new.text);
+END;
+`
+
+// Database is a SQLite database shared by all conversions of a run. Chats
+// are written one at a time.
+type Database struct {
+	db *sql.DB
+	mu sync.Mutex
+
+	// Media inventory of all chats, written once after the run
+	inventoryMu sync.Mutex
+	inventory   []mediaItem
+}
+
+// OpenDatabase opens or creates a SQLite database with the export schema
+func OpenDatabase(path string) (*Database, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA journal_mode=WAL; PRAGMA busy_timeout=5000;"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to configure database: %w", err)
+	}
+
+	if _, err := db.Exec(databaseSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database schema: %w", err)
+	}
+
+	return &Database{db: db}, nil
+}
+
+// Close closes the database
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// SetDatabase sets the database chats are written to in SQLite mode
+func (p *JSONToMarkdown) SetDatabase(database *Database) {
+	p.database = database
+}
+
+// writeDatabase upserts all chats into the database. Messages are keyed by
+// chat and message ID, so newer exports of the same chat only add messages.
+func (p *JSONToMarkdown) writeDatabase(exports []telegram.Export) error {
+	if p.database == nil {
+		return fmt.Errorf("no database opened for SQLite output")
+	}
+
+	for i := range exports {
+		if err := p.writeChat(&exports[i]); err != nil {
+			return err
+		}
+
+		if p.mediaInventory {
+			p.database.addInventory(p.collectMedia(&exports[i]))
+		}
+	}
+
+	return nil
+}
+
+// addInventory adds media items of a converted chat to the run inventory
+func (d *Database) addInventory(items []mediaItem) {
+	d.inventoryMu.Lock()
+	defer d.inventoryMu.Unlock()
+	d.inventory = append(d.inventory, items...)
+}
+
+// WriteInventory writes the media inventory of all chats converted into the
+// database to a CSV file next to it, optionally starting with a BOM
+func (d *Database) WriteInventory(databasePath string, bom bool) error {
+	d.inventoryMu.Lock()
+	defer d.inventoryMu.Unlock()
+	return writeInventoryCSV(inventoryPath(databasePath), d.inventory, bom)
+}
+
+// writeChat upserts a chat with its messages in a single transaction
+func (p *JSONToMarkdown) writeChat(export *telegram.Export) error {
+	p.database.mu.Lock()
+	defer p.database.mu.Unlock()
+
+	tx, err := p.database.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO chats (id, name, type) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = excluded.name, type = excluded.type`,
+		export.ID, export.Name, export.Type)
+	if err != nil {
+		return fmt.Errorf("failed to write chat: %w", err)
+	}
+
+	for i := range export.Messages {
+		record := p.messageRecord(export, &export.Messages[i])
+		if err := writeMessageRecord(tx, &record); err != nil {
+			return fmt.Errorf("failed to write message %d: %w", record.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chat: %w", err)
+	}
+
+	return nil
+}
+
+// writeMessageRecord upserts a message and replaces its entities, media and
+// reactions
+func writeMessageRecord(tx *sql.Tx, record *MessageRecord) error {
+	if record.SenderID != "" {
+		_, err := tx.Exec(`INSERT INTO users (id, name) VALUES (?, ?)
+			ON CONFLICT(id) DO UPDATE SET name = excluded.name`,
+			record.SenderID, record.SenderName)
+		if err != nil {
+			return err
+		}
+	}
+
+	var forwardedFrom, forwardedFromID, savedFrom string
+	if record.Forward != nil {
+		forwardedFrom = record.Forward.From
+		forwardedFromID = record.Forward.FromID
+		savedFrom = record.Forward.SavedFrom
+	}
+
+	_, err := tx.Exec(`INSERT INTO messages (chat_id, id, type, timestamp, edited, sender_id, sender_name,
+			text, markdown, reply_to_id, forwarded_from, forwarded_from_id, saved_from, action)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(chat_id, id) DO UPDATE SET
+			type = excluded.type, timestamp = excluded.timestamp, edited = excluded.edited,
+			sender_id = excluded.sender_id, sender_name = excluded.sender_name,
+			text = excluded.text, markdown = excluded.markdown, reply_to_id = excluded.reply_to_id,
+			forwarded_from = excluded.forwarded_from, forwarded_from_id = excluded.forwarded_from_id,
+			saved_from = excluded.saved_from, action = excluded.action`,
+		record.ChatID, record.ID, record.Type, record.Timestamp, nullString(record.Edited),
+		nullString(record.SenderID), nullString(record.SenderName), record.Text, record.Markdown,
+		nullInt(record.ReplyToID), nullString(forwardedFrom), nullString(forwardedFromID),
+		nullString(savedFrom), nullString(record.Action))
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{"entities", "media", "reactions"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE chat_id = ? AND message_id = ?", record.ChatID, record.ID); err != nil {
+			return err
+		}
+	}
+
+	for position, entity := range record.Entities {
+		_, err := tx.Exec(`INSERT INTO entities (chat_id, message_id, position, type, text, href, user_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			record.ChatID, record.ID, position, entity.Type, entity.Text,
+			nullString(entity.Href), nullString(entity.UserID))
+		if err != nil {
+			return err
+		}
+	}
+
+	if media := record.Media; media != nil {
+		_, err := tx.Exec(`INSERT INTO media (chat_id, message_id, type, path, file_name, mime_type,
+				size, width, height, duration, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			record.ChatID, record.ID, media.Type, nullString(media.Path), nullString(media.FileName),
+			nullString(media.MimeType), nullInt(media.Size), nullInt(int64(media.Width)),
+			nullInt(int64(media.Height)), nullInt(int64(media.Duration)), media.Status)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, reaction := range record.Reactions {
+		_, err := tx.Exec(`INSERT INTO reactions (chat_id, message_id, emoji, count) VALUES (?, ?, ?, ?)
+			ON CONFLICT(chat_id, message_id, emoji) DO UPDATE SET count = excluded.count`,
+			record.ChatID, record.ID, reaction.Emoji, reaction.Count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nullString stores empty strings as NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// nullInt stores zero numbers as NULL
+func nullInt(value int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: value, Valid: value != 0}
+}
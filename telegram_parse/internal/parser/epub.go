@@ -0,0 +1,577 @@
+package parser
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"telegram_parse/internal/telegram"
+)
+
+// epubStyle is the stylesheet of generated books
+const epubStyle = `body { font-family: serif; line-height: 1.4; }
+h1 { text-align: center; }
+h2 { margin-top: 1.5em; }
+.message { margin: 0.8em 0; padding: 0.4em 0.6em; border-left: 3px solid #5b9bd5; page-break-inside: avoid; }
+.meta { margin: 0; font-size: 0.8em; color: #666; }
+.from { font-weight: bold; color: #2e6da4; }
+.text { margin: 0.3em 0; }
+.media, .reply, .forward, .reactions { margin: 0.2em 0; font-size: 0.85em; color: #555; }
+.service { text-align: center; font-style: italic; font-size: 0.85em; color: #777; margin: 0.8em 0; }
+.spoiler { background: #ccc; color: #ccc; }
+figure { margin: 0.4em 0; text-align: center; }
+img { max-width: 100%; }
+pre { white-space: pre-wrap; font-size: 0.85em; }
+`
+
+// epubChapter is a chapter of an e-book with messages of one month
+type epubChapter struct {
+	File     string
+	Title    string
+	Chat     string
+	ChatID   int // Index of the chat in the export, scoping message anchors
+	Messages []*telegram.Message
+}
+
+// epubImage is a photo copied from the export into the book
+type epubImage struct {
+	Source string // Path within the export file system
+	File   string // Path within the book
+	Type   string
+}
+
+// epubBook collects the contents of an e-book while it is rendered
+type epubBook struct {
+	chapters []*epubChapter
+	images   map[string]*epubImage // By media path in the export
+	order    []*epubImage
+	location map[epubMessage]string // Chapter file of each message, for reply links
+}
+
+// epubMessage identifies a message of a chat in the book. Message IDs are
+// only unique within a chat, so full account exports need both.
+type epubMessage struct {
+	Chat int
+	ID   int64
+}
+
+// epubAnchor returns the element ID of a message in the book
+func epubAnchor(chat int, id int64) string {
+	return fmt.Sprintf("chat-%d-%s", chat, messageAnchor(id))
+}
+
+// writeEPUB writes all chats as an e-book with a chapter per month
+func (p *JSONToMarkdown) writeEPUB(exports []telegram.Export, outputPath string) error {
+	book := &epubBook{
+		images:   make(map[string]*epubImage),
+		location: make(map[epubMessage]string),
+	}
+
+	// Chapters per month, built on the same grouping as static sites
+	monthly := *p
+	monthly.sitePages = SitePageMonth
+	for i := range exports {
+		for _, page := range monthly.sitePagesOf(&exports[i]) {
+			chapter := &epubChapter{
+				File:     fmt.Sprintf("chapter-%03d.xhtml", len(book.chapters)+1),
+				Title:    p.formatDate(page.Date, "January 2006"),
+				Chat:     exports[i].Name,
+				ChatID:   i,
+				Messages: page.Messages,
+			}
+			for _, msg := range page.Messages {
+				book.location[epubMessage{i, msg.ID}] = chapter.File
+			}
+			book.chapters = append(book.chapters, chapter)
+		}
+
+		if p.mediaInventory {
+			p.conv.inventory = append(p.conv.inventory, p.collectMedia(&exports[i])...)
+		}
+	}
+
+	title := p.tr("Telegram export")
+	if len(exports) == 1 && exports[0].Name != "" {
+		title = exports[0].Name
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	if err := p.writeEPUBArchive(file, book, title, len(exports) > 1); err != nil {
+		file.Close()
+		os.Remove(outputPath)
+		return err
+	}
+
+	return nil
+}
+
+// writeEPUBArchive writes the book files into a ZIP container
+func (p *JSONToMarkdown) writeEPUBArchive(w io.Writer, book *epubBook, title string, multiChat bool) error {
+	archive := zip.NewWriter(w)
+
+	// The mimetype must come first and be stored uncompressed
+	mimetype, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to write e-book: %w", err)
+	}
+	io.WriteString(mimetype, "application/epub+zip")
+
+	files := map[string]string{
+		"META-INF/container.xml": epubContainer,
+		"OEBPS/style.css":        epubStyle,
+	}
+
+	// Chapters are rendered first so that referenced images are known
+	for _, chapter := range book.chapters {
+		files["OEBPS/"+chapter.File] = p.epubChapterXHTML(book, chapter, multiChat)
+	}
+	files["OEBPS/nav.xhtml"] = p.epubNav(book, title, multiChat)
+	files["OEBPS/toc.ncx"] = epubNCX(book, title)
+	files["OEBPS/content.opf"] = epubPackage(book, title, p.bookLanguage(), time.Now())
+
+	names := []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/toc.ncx", "OEBPS/style.css"}
+	for _, chapter := range book.chapters {
+		names = append(names, "OEBPS/"+chapter.File)
+	}
+
+	for _, name := range names {
+		entry, err := archive.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to write e-book: %w", err)
+		}
+		if _, err := io.WriteString(entry, files[name]); err != nil {
+			return fmt.Errorf("failed to write e-book: %w", err)
+		}
+	}
+
+	for _, image := range book.order {
+		if err := p.copyEPUBImage(archive, image); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write e-book: %w", err)
+	}
+
+	return nil
+}
+
+// copyEPUBImage copies a photo from the export into the book
+func (p *JSONToMarkdown) copyEPUBImage(archive *zip.Writer, image *epubImage) error {
+	in, err := p.conv.fsys.Open(image.Source)
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+	defer in.Close()
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: "OEBPS/" + image.File, Method: zip.Store})
+	if err != nil {
+		return fmt.Errorf("failed to write e-book: %w", err)
+	}
+
+	if _, err := io.Copy(entry, in); err != nil {
+		return fmt.Errorf("failed to copy image: %w", err)
+	}
+
+	return nil
+}
+
+// epubChapterXHTML renders the messages of a chapter
+func (p *JSONToMarkdown) epubChapterXHTML(book *epubBook, chapter *epubChapter, multiChat bool) string {
+	var body strings.Builder
+
+	heading := chapter.Title
+	if multiChat {
+		heading = chapter.Chat + " — " + chapter.Title
+	}
+	body.WriteString(fmt.Sprintf("<h2>%s</h2>\n", html.EscapeString(heading)))
+
+	for _, msg := range chapter.Messages {
+		body.WriteString(p.messageToXHTML(book, chapter.ChatID, msg))
+	}
+
+	return xhtmlDocument(heading, body.String())
+}
+
+// messageToXHTML renders a message of a chat as a styled block
+func (p *JSONToMarkdown) messageToXHTML(book *epubBook, chat int, msg *telegram.Message) string {
+	var result strings.Builder
+
+	if msg.Type == "service" {
+		sentence := p.describeAction(msg)
+		if sentence == "" {
+			sentence = msg.Action
+		}
+		if sentence == "" {
+			return ""
+		}
+		result.WriteString(fmt.Sprintf("<p class=\"service\" id=\"%s\">%s</p>\n", epubAnchor(chat, msg.ID), book.relinkMessages(chat, html.EscapeString(sentence))))
+		return result.String()
+	}
+
+	result.WriteString(fmt.Sprintf("<div class=\"message\" id=\"%s\">\n", epubAnchor(chat, msg.ID)))
+
+	result.WriteString("<p class=\"meta\">")
+	if msg.Date != "" {
+		result.WriteString(fmt.Sprintf("<span class=\"date\">%s</span> ", html.EscapeString(p.displayDate(msg.Date))))
+	}
+	sender := msg.From
+	if msg.Author != "" {
+		sender = msg.Author
+	}
+	if sender != "" {
+		result.WriteString(fmt.Sprintf("<span class=\"from\">%s</span>", html.EscapeString(sender)))
+	}
+	result.WriteString("</p>\n")
+
+	if msg.ForwardedFrom != "" {
+		result.WriteString(fmt.Sprintf("<p class=\"forward\">%s</p>\n", html.EscapeString(p.tr("Forwarded from %s", msg.ForwardedFrom))))
+	}
+
+	if msg.ReplyToMessageID != 0 {
+		if chapterFile, ok := book.location[epubMessage{chat, msg.ReplyToMessageID}]; ok {
+			link := fmt.Sprintf("<a href=\"%s#%s\">%s %d</a>",
+				chapterFile, epubAnchor(chat, msg.ReplyToMessageID), html.EscapeString(p.tr("message")), msg.ReplyToMessageID)
+			result.WriteString(fmt.Sprintf("<p class=\"reply\">%s</p>\n", p.tr("Reply to %s", link)))
+		} else {
+			label := fmt.Sprintf("%s %d", p.tr("message"), msg.ReplyToMessageID)
+			result.WriteString(fmt.Sprintf("<p class=\"reply\">%s</p>\n", html.EscapeString(p.tr("Reply to %s", label))))
+		}
+	}
+
+	if text := htmlText(msg.Text); text != "" {
+		result.WriteString(fmt.Sprintf("<div class=\"text\">%s</div>\n", text))
+	}
+
+	if p.includeMedia {
+		result.WriteString(p.mediaToXHTML(book, msg))
+	}
+
+	if msg.Poll != nil {
+		result.WriteString(fmt.Sprintf("<p class=\"media\">📊 %s</p>\n<ul>\n", html.EscapeString(msg.Poll.Question)))
+		for _, answer := range msg.Poll.Answers {
+			result.WriteString(fmt.Sprintf("<li>%s (%s)</li>\n", html.EscapeString(answer.Text), p.plural(answer.Voters, "vote")))
+		}
+		result.WriteString("</ul>\n")
+	}
+
+	if contact := msg.ContactInformation; contact != nil {
+		name := strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+		result.WriteString(fmt.Sprintf("<p class=\"media\">👤 %s %s</p>\n", html.EscapeString(name), html.EscapeString(contact.PhoneNumber)))
+	}
+
+	if location := msg.LocationInformation; location != nil {
+		result.WriteString(fmt.Sprintf("<p class=\"media\">📍 %.6f, %.6f</p>\n", location.Latitude, location.Longitude))
+	}
+
+	if len(msg.Reactions) > 0 {
+		var parts []string
+		for _, reaction := range msg.Reactions {
+			emoji := reaction.Emoji
+			if emoji == "" {
+				emoji = "🧩"
+			}
+			parts = append(parts, fmt.Sprintf("%s %d", emoji, reaction.Count))
+		}
+		result.WriteString(fmt.Sprintf("<p class=\"reactions\">%s</p>\n", html.EscapeString(strings.Join(parts, " · "))))
+	}
+
+	result.WriteString("</div>\n")
+	return result.String()
+}
+
+// relinkMessages turns Markdown links to messages of a chat into links to
+// their chapters
+func (book *epubBook) relinkMessages(chat int, text string) string {
+	return relinkMessages(text, func(id int64, label string) string {
+		chapterFile, ok := book.location[epubMessage{chat, id}]
+		if !ok {
+			return label
+		}
+		return fmt.Sprintf("<a href=\"%s#%s\">%s</a>", chapterFile, epubAnchor(chat, id), label)
+	})
+}
+
+// mediaToXHTML embeds photos present in the export and describes other media
+func (p *JSONToMarkdown) mediaToXHTML(book *epubBook, msg *telegram.Message) string {
+	var result strings.Builder
+
+	if msg.Photo != "" {
+		if image := p.epubImage(book, msg.Photo); image != nil {
+			result.WriteString(fmt.Sprintf("<figure><img src=\"%s\" alt=\"%s\"/></figure>\n",
+				html.EscapeString(image.File), html.EscapeString(filepath.Base(msg.Photo))))
+		} else if status, _ := p.statMedia(msg.Photo); status == MediaNotIncluded {
+			result.WriteString(fmt.Sprintf("<p class=\"media\">📷 %s %s</p>\n", p.tr("Photo"), p.tr("not included in the export")))
+		} else if status == MediaMissing {
+			result.WriteString(fmt.Sprintf("<p class=\"media\">📷 %s %s (%s)</p>\n",
+				p.tr("Photo"), html.EscapeString(filepath.Base(msg.Photo)), p.tr("missing")))
+		} else {
+			result.WriteString(fmt.Sprintf("<p class=\"media\">📷 %s %s</p>\n", p.tr("Photo"), html.EscapeString(filepath.Base(msg.Photo))))
+		}
+	}
+
+	if msg.File != "" || (msg.MediaType != "" && msg.MediaType != "photo") {
+		// Stickers with a still image are shown as pictures
+		if msg.MediaType == "sticker" && isImageFile(msg.Thumbnail) {
+			if image := p.epubImage(book, msg.Thumbnail); image != nil {
+				result.WriteString(fmt.Sprintf("<figure><img src=\"%s\" alt=\"%s\"/></figure>\n",
+					html.EscapeString(image.File), html.EscapeString(msg.StickerEmoji)))
+				return result.String()
+			}
+		}
+
+		emoji, label := p.mediaLabel(msg.MediaType)
+		name := msg.FileName
+		if name == "" && msg.File != "" && !isMediaPlaceholder(msg.File) {
+			name = filepath.Base(msg.File)
+		}
+		if msg.MediaType == "sticker" && msg.StickerEmoji != "" {
+			name = msg.StickerEmoji
+		}
+
+		details := ""
+		if duration := mediaDuration(msg); duration > 0 {
+			details = fmt.Sprintf(" (%s)", formatDuration(duration))
+		}
+
+		result.WriteString(fmt.Sprintf("<p class=\"media\">%s %s: %s%s</p>\n",
+			emoji, html.EscapeString(label), html.EscapeString(name), details))
+	}
+
+	return result.String()
+}
+
+// epubImage adds an image of the export to the book, nil if it is missing
+func (p *JSONToMarkdown) epubImage(book *epubBook, mediaPath string) *epubImage {
+	if image, ok := book.images[mediaPath]; ok {
+		return image
+	}
+
+	if p.mediaStatus(mediaPath) != MediaPresent || !isImageFile(mediaPath) {
+		return nil
+	}
+
+	clean := path.Clean(filepath.ToSlash(mediaPath))
+	ext := strings.ToLower(path.Ext(clean))
+	image := &epubImage{
+		Source: path.Join(p.conv.baseDir, clean),
+		File:   fmt.Sprintf("images/image-%04d%s", len(book.order)+1, ext),
+		Type:   mime.TypeByExtension(ext),
+	}
+	if image.Type == "" || ext == ".jpg" {
+		image.Type = "image/jpeg"
+	}
+
+	book.images[mediaPath] = image
+	book.order = append(book.order, image)
+	return image
+}
+
+// htmlText renders message text with entity formatting as XHTML
+func htmlText(text interface{}) string {
+	var result strings.Builder
+
+	switch t := text.(type) {
+	case string:
+		result.WriteString(htmlLineBreaks(html.EscapeString(t)))
+	case []interface{}:
+		for _, item := range t {
+			switch v := item.(type) {
+			case string:
+				result.WriteString(htmlLineBreaks(html.EscapeString(v)))
+			case map[string]interface{}:
+				textVal, _ := v["text"].(string)
+				typeVal, _ := v["type"].(string)
+				href, _ := v["href"].(string)
+
+				// Code keeps its own line breaks
+				entity := htmlEntity(textVal, typeVal, href)
+				if typeVal != "pre" && typeVal != "code" {
+					entity = htmlLineBreaks(entity)
+				}
+				result.WriteString(entity)
+			}
+		}
+	}
+
+	return result.String()
+}
+
+// htmlLineBreaks turns line breaks of text into XHTML line breaks
+func htmlLineBreaks(text string) string {
+	return strings.ReplaceAll(text, "\n", "<br/>\n")
+}
+
+// htmlEntity applies XHTML formatting based on entity type
+func htmlEntity(text, entityType, href string) string {
+	escaped := html.EscapeString(text)
+
+	switch entityType {
+	case "bold":
+		return "<strong>" + escaped + "</strong>"
+	case "italic":
+		return "<em>" + escaped + "</em>"
+	case "code":
+		return "<code>" + escaped + "</code>"
+	case "pre":
+		return "<pre>" + escaped + "</pre>"
+	case "text_link":
+		if !safeURL(href) {
+			return escaped
+		}
+		return fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(href), escaped)
+	case "link":
+		// Links detected in text may lack a scheme, such as "example.com"
+		href = text
+		if u, err := url.Parse(href); err == nil && u.Scheme == "" {
+			href = "http://" + href
+		}
+		if !safeURL(href) {
+			return escaped
+		}
+		return fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(href), escaped)
+	case "email":
+		return fmt.Sprintf("<a href=\"mailto:%s\">%s</a>", escaped, escaped)
+	case "strikethrough":
+		return "<del>" + escaped + "</del>"
+	case "underline":
+		return "<u>" + escaped + "</u>"
+	case "spoiler":
+		return "<span class=\"spoiler\">" + escaped + "</span>"
+	case "blockquote":
+		return "<blockquote>" + escaped + "</blockquote>"
+	default:
+		return escaped
+	}
+}
+
+// safeLinkSchemes lists URL schemes written as links to HTML output
+var safeLinkSchemes = map[string]bool{"http": true, "https": true, "mailto": true, "tg": true}
+
+// safeURL reports whether a URL can be linked from HTML output. Links with
+// other schemes, such as javascript:, are written as text.
+func safeURL(href string) bool {
+	u, err := url.Parse(href)
+	return err == nil && safeLinkSchemes[strings.ToLower(u.Scheme)]
+}
+
+// xhtmlDocument wraps a body into an XHTML content document
+func xhtmlDocument(title, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+<meta charset="UTF-8"/>
+<title>%s</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+%s</body>
+</html>
+`, html.EscapeString(title), body)
+}
+
+// epubContainer points readers to the package document
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+// epubIdentifier derives a stable book identifier from the chapters
+func epubIdentifier(book *epubBook, title string) string {
+	hash := sha1.New()
+	io.WriteString(hash, title)
+	for _, chapter := range book.chapters {
+		io.WriteString(hash, chapter.Chat+chapter.Title)
+	}
+	sum := hash.Sum(nil)
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// epubPackage returns the package document listing all book files
+func epubPackage(book *epubBook, title, language string, modified time.Time) string {
+	var manifest, spine strings.Builder
+
+	manifest.WriteString("<item id=\"nav\" href=\"nav.xhtml\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n")
+	manifest.WriteString("<item id=\"ncx\" href=\"toc.ncx\" media-type=\"application/x-dtbncx+xml\"/>\n")
+	manifest.WriteString("<item id=\"style\" href=\"style.css\" media-type=\"text/css\"/>\n")
+	spine.WriteString("<itemref idref=\"nav\"/>\n")
+
+	for i, chapter := range book.chapters {
+		manifest.WriteString(fmt.Sprintf("<item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapter.File))
+		spine.WriteString(fmt.Sprintf("<itemref idref=\"chapter-%d\"/>\n", i+1))
+	}
+
+	for i, image := range book.order {
+		manifest.WriteString(fmt.Sprintf("<item id=\"image-%d\" href=\"%s\" media-type=\"%s\"/>\n", i+1, image.File, image.Type))
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="book-id">%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:language>%s</dc:language>
+<dc:creator>Telegram JSON to Markdown Parser</dc:creator>
+<meta property="dcterms:modified">%s</meta>
+</metadata>
+<manifest>
+%s</manifest>
+<spine toc="ncx">
+%s</spine>
+</package>
+`, epubIdentifier(book, title), html.EscapeString(title), language, modified.UTC().Format("2006-01-02T15:04:05Z"), manifest.String(), spine.String())
+}
+
+// epubNav returns the table of contents of the book
+func (p *JSONToMarkdown) epubNav(book *epubBook, title string, multiChat bool) string {
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("<h1>%s</h1>\n<nav epub:type=\"toc\" id=\"toc\">\n<h2>%s</h2>\n<ol>\n", html.EscapeString(title), html.EscapeString(p.tr("Contents"))))
+
+	for _, chapter := range book.chapters {
+		label := chapter.Title
+		if multiChat {
+			label = chapter.Chat + " — " + chapter.Title
+		}
+		body.WriteString(fmt.Sprintf("<li><a href=\"%s\">%s</a></li>\n", chapter.File, html.EscapeString(label)))
+	}
+
+	body.WriteString("</ol>\n</nav>\n")
+	return xhtmlDocument(title, body.String())
+}
+
+// epubNCX returns the table of contents for EPUB 2 readers
+func epubNCX(book *epubBook, title string) string {
+	var points strings.Builder
+	for i, chapter := range book.chapters {
+		points.WriteString(fmt.Sprintf("<navPoint id=\"nav-%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/></navPoint>\n",
+			i+1, i+1, html.EscapeString(chapter.Chat+" — "+chapter.Title), chapter.File))
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+<head><meta name="dtb:uid" content="%s"/></head>
+<docTitle><text>%s</text></docTitle>
+<navMap>
+%s</navMap>
+</ncx>
+`, epubIdentifier(book, title), html.EscapeString(title), points.String())
+}
@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Markdown flavors controlling how formatted message text is written
+const (
+	FlavorCommonMark = "commonmark" // CommonMark, inline HTML for formatting it lacks
+	FlavorGFM        = "gfm"        // GitHub Flavored Markdown
+	FlavorTelegram   = "telegram"   // Telegram MarkdownV2 for re-posting through bots
+	FlavorDiscord    = "discord"    // Discord message formatting
+)
+
+// whitespacePattern matches runs of whitespace collapsed in message text
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// numericPattern matches custom emoji IDs, exports may contain a file path or
+// a placeholder instead
+var numericPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// markdownEscaper escapes characters with a meaning in Markdown
+var markdownEscaper = escaper("\\", "`", "*", "_", "{", "}", "[", "]", "(", ")", "#", "+", "-", ".", "!", "|")
+
+// telegramEscaper escapes all characters reserved by MarkdownV2
+var telegramEscaper = escaper("\\", "_", "*", "[", "]", "(", ")", "~", "`", ">", "#", "+", "-", "=", "|", "{", "}", ".", "!")
+
+// telegramCodeEscaper escapes characters reserved inside MarkdownV2 code
+var telegramCodeEscaper = escaper("\\", "`")
+
+// telegramURLEscaper escapes characters reserved inside MarkdownV2 link targets
+var telegramURLEscaper = escaper("\\", ")")
+
+// escaper returns a replacer prefixing each of the characters with a backslash
+func escaper(chars ...string) *strings.Replacer {
+	var pairs []string
+	for _, char := range chars {
+		pairs = append(pairs, char, "\\"+char)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// escapeText escapes plain message text for the flavor
+func (p *JSONToMarkdown) escapeText(text string) string {
+	if p.flavor == FlavorTelegram {
+		return telegramEscaper.Replace(text)
+	}
+	return markdownEscaper.Replace(text)
+}
+
+// codeSpan returns inline code. Markdown fences are made longer than any
+// backtick run in the code.
+func (p *JSONToMarkdown) codeSpan(code string) string {
+	code = whitespacePattern.ReplaceAllString(code, " ")
+	if p.flavor == FlavorTelegram {
+		return wrapMarker(telegramCodeEscaper.Replace(code), "`", "`")
+	}
+
+	fence := "`"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// codeBlock returns a fenced code block on lines of its own
+func (p *JSONToMarkdown) codeBlock(code, language string) string {
+	code = strings.Trim(code, "\n")
+	if p.flavor == FlavorTelegram {
+		return fmt.Sprintf("\n```%s\n%s\n```\n", language, telegramCodeEscaper.Replace(code))
+	}
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("\n%s%s\n%s\n%s\n", fence, language, code, fence)
+}
+
+// inlineLink returns a link with an already escaped label
+func (p *JSONToMarkdown) inlineLink(label, href string) string {
+	if p.flavor == FlavorTelegram {
+		href = telegramURLEscaper.Replace(href)
+	}
+	return fmt.Sprintf("[%s](%s)", label, href)
+}
+
+// autoLink returns a URL or email address as a link
+func (p *JSONToMarkdown) autoLink(text string) string {
+	switch p.flavor {
+	case FlavorCommonMark:
+		// CommonMark only links URLs and addresses in angle brackets
+		return "<" + text + ">"
+	case FlavorTelegram:
+		return telegramEscaper.Replace(text)
+	default:
+		return text
+	}
+}
+
+// strikethrough returns struck out text
+func (p *JSONToMarkdown) strikethrough(text string) string {
+	switch p.flavor {
+	case FlavorCommonMark:
+		return wrapMarker(text, "<del>", "</del>")
+	case FlavorTelegram:
+		return wrapMarker(text, "~", "~")
+	default:
+		return wrapMarker(text, "~~", "~~")
+	}
+}
+
+// underline returns underlined text. Markdown reads "__" as bold, so HTML is
+// used there.
+func (p *JSONToMarkdown) underline(text string) string {
+	switch p.flavor {
+	case FlavorTelegram, FlavorDiscord:
+		return wrapMarker(text, "__", "__")
+	default:
+		return wrapMarker(text, "<u>", "</u>")
+	}
+}
+
+// spoiler returns text hidden until revealed
+func (p *JSONToMarkdown) spoiler(text string) string {
+	switch p.flavor {
+	case FlavorTelegram, FlavorDiscord:
+		return wrapMarker(text, "||", "||")
+	case FlavorGFM:
+		return wrapMarker(text, "<details><summary>"+p.tr("Spoiler")+"</summary>", "</details>")
+	default:
+		return wrapMarker(text, "<span class=\"spoiler\">", "</span>")
+	}
+}
+
+// customEmoji returns a custom emoji. Only Telegram can show the emoji
+// itself, other flavors fall back to its standard emoji.
+func (p *JSONToMarkdown) customEmoji(text string, entity map[string]interface{}) string {
+	if p.flavor == FlavorTelegram {
+		if id := fmt.Sprint(entity["document_id"]); numericPattern.MatchString(id) {
+			return fmt.Sprintf("![%s](tg://emoji?id=%s)", telegramEscaper.Replace(text), id)
+		}
+	}
+	return p.escapeText(text)
+}
+
+// blockquote returns quoted text on lines of its own
+func (p *JSONToMarkdown) blockquote(text string) string {
+	text = strings.TrimSpace(text)
+	if p.flavor == FlavorTelegram {
+		return "\n>" + text + "\n"
+	}
+	if p.flavor == FlavorDiscord {
+		return "\n> " + text + "\n"
+	}
+	return "\n\n> " + text + "\n\n"
+}
@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"testing"
+
+	"telegram_parse/internal/models"
+)
+
+// flavors lists all Markdown flavors in the order of expected outputs
+var flavors = []string{FlavorCommonMark, FlavorGFM, FlavorTelegram, FlavorDiscord}
+
+func newFlavorConverter(flavor string) *JSONToMarkdown {
+	return NewJSONToMarkdownWithOptions(models.ProcessOptions{MarkdownFlavor: flavor})
+}
+
+func textEntity(entityType, text string, fields ...string) map[string]interface{} {
+	result := map[string]interface{}{"type": entityType, "text": text}
+	for i := 0; i+1 < len(fields); i += 2 {
+		result[fields[i]] = fields[i+1]
+	}
+	return result
+}
+
+func TestFormatTextFlavors(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity map[string]interface{}
+		want   []string // CommonMark, GFM, Telegram, Discord
+	}{
+		{
+			name:   "bold",
+			entity: textEntity("bold", "strong"),
+			want:   []string{"**strong**", "**strong**", "*strong*", "**strong**"},
+		},
+		{
+			name:   "bold keeps spaces outside markers",
+			entity: textEntity("bold", "strong "),
+			want:   []string{"**strong** ", "**strong** ", "*strong* ", "**strong** "},
+		},
+		{
+			name:   "italic",
+			entity: textEntity("italic", "soft"),
+			want:   []string{"*soft*", "*soft*", "_soft_", "*soft*"},
+		},
+		{
+			name:   "code is not escaped",
+			entity: textEntity("code", "a_b.c"),
+			want:   []string{"`a_b.c`", "`a_b.c`", "`a_b.c`", "`a_b.c`"},
+		},
+		{
+			name:   "code with backticks",
+			entity: textEntity("code", "x`y"),
+			want:   []string{"``x`y``", "``x`y``", "`x\\`y`", "``x`y``"},
+		},
+		{
+			name:   "pre keeps lines and language",
+			entity: textEntity("pre", "a := 1\nb := 2", "language", "go"),
+			want: []string{
+				"\n```go\na := 1\nb := 2\n```\n",
+				"\n```go\na := 1\nb := 2\n```\n",
+				"\n```go\na := 1\nb := 2\n```\n",
+				"\n```go\na := 1\nb := 2\n```\n",
+			},
+		},
+		{
+			name:   "text link",
+			entity: textEntity("text_link", "docs", "href", "https://e.x/a_(b)"),
+			want: []string{
+				"[docs](https://e.x/a_(b))",
+				"[docs](https://e.x/a_(b))",
+				"[docs](https://e.x/a_(b\\))",
+				"[docs](https://e.x/a_(b))",
+			},
+		},
+		{
+			name:   "link",
+			entity: textEntity("link", "https://x.y/a_b"),
+			want:   []string{"<https://x.y/a_b>", "https://x.y/a_b", "https://x\\.y/a\\_b", "https://x.y/a_b"},
+		},
+		{
+			name:   "strikethrough",
+			entity: textEntity("strikethrough", "old"),
+			want:   []string{"<del>old</del>", "~~old~~", "~old~", "~~old~~"},
+		},
+		{
+			name:   "underline",
+			entity: textEntity("underline", "key"),
+			want:   []string{"<u>key</u>", "<u>key</u>", "__key__", "__key__"},
+		},
+		{
+			name:   "spoiler",
+			entity: textEntity("spoiler", "end"),
+			want: []string{
+				"<span class=\"spoiler\">end</span>",
+				"<details><summary>Spoiler</summary>end</details>",
+				"||end||",
+				"||end||",
+			},
+		},
+		{
+			name:   "custom emoji",
+			entity: textEntity("custom_emoji", "👍", "document_id", "5368324170671202286"),
+			want:   []string{"👍", "👍", "![👍](tg://emoji?id=5368324170671202286)", "👍"},
+		},
+		{
+			name:   "custom emoji without ID",
+			entity: textEntity("custom_emoji", "👍", "document_id", "(File not included. Change data exporting settings to download.)"),
+			want:   []string{"👍", "👍", "👍", "👍"},
+		},
+		{
+			name:   "blockquote",
+			entity: textEntity("blockquote", "quoted"),
+			want:   []string{"\n\n> quoted\n\n", "\n\n> quoted\n\n", "\n>quoted\n", "\n> quoted\n"},
+		},
+		{
+			name:   "hashtag keeps a single sign",
+			entity: textEntity("hashtag", "#release"),
+			want:   []string{"\\#release", "\\#release", "\\#release", "\\#release"},
+		},
+		{
+			name:   "mention keeps a single sign",
+			entity: textEntity("mention", "@bob"),
+			want:   []string{"@bob", "@bob", "@bob", "@bob"},
+		},
+	}
+
+	for _, test := range tests {
+		for i, flavor := range flavors {
+			p := newFlavorConverter(flavor)
+			text, _ := test.entity["text"].(string)
+			entityType, _ := test.entity["type"].(string)
+
+			if got := p.formatText(text, entityType, test.entity); got != test.want[i] {
+				t.Errorf("%s (%s): got %q, want %q", test.name, flavor, got, test.want[i])
+			}
+		}
+	}
+}
+
+func TestEscapeFlavors(t *testing.T) {
+	text := "1.5 + 2 = 3.5 (approx) > x!"
+	want := []string{
+		"1\\.5 \\+ 2 = 3\\.5 \\(approx\\) > x\\!",
+		"1\\.5 \\+ 2 = 3\\.5 \\(approx\\) > x\\!",
+		"1\\.5 \\+ 2 \\= 3\\.5 \\(approx\\) \\> x\\!",
+		"1\\.5 \\+ 2 = 3\\.5 \\(approx\\) > x\\!",
+	}
+
+	for i, flavor := range flavors {
+		if got := newFlavorConverter(flavor).extractTextContent(text, nil); got != want[i] {
+			t.Errorf("%s: got %q, want %q", flavor, got, want[i])
+		}
+	}
+}
+
+func TestTextEntitiesKeepSpaces(t *testing.T) {
+	text := []interface{}{
+		"Hello ",
+		textEntity("bold", "world"),
+		" see ",
+		textEntity("link", "https://x.y"),
+		" and ",
+		textEntity("hashtag", "#news"),
+		"  ",
+	}
+
+	want := "Hello **world** see https://x.y and \\#news"
+	if got := newFlavorConverter(FlavorGFM).extractTextContent(text, nil); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDefaultFlavor(t *testing.T) {
+	if got := NewJSONToMarkdown().formatText("key", "underline", nil); got != "<u>key</u>" {
+		t.Errorf("default flavor underline: got %q, want GFM output", got)
+	}
+}
+
+func TestObsidianHashtag(t *testing.T) {
+	p := NewJSONToMarkdownWithOptions(models.ProcessOptions{OutputFormat: FormatObsidian})
+	if got := p.formatText("#release-v2", "hashtag", nil); got != "#release-v2" {
+		t.Errorf("got %q, want %q", got, "#release-v2")
+	}
+}
@@ -0,0 +1,265 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"telegram_parse/internal/telegram"
+)
+
+// Header styles of Markdown output
+const (
+	HeaderMarkdown = "markdown" // Bold fields under the chat heading
+	HeaderYAML     = "yaml"     // YAML front matter between "---" lines
+	HeaderTOML     = "toml"     // TOML front matter between "+++" lines
+	HeaderNone     = "none"     // No chat header
+)
+
+// Optional header fields, added to the chat name, type, ID and message count
+const (
+	HeaderFieldDates        = "dates"        // Dates of the first and the last message
+	HeaderFieldParticipants = "participants" // Senders with their message counts
+	HeaderFieldSource       = "source"       // Path of the converted JSON file
+	HeaderFieldGenerated    = "generated"    // Time of the conversion
+	HeaderFieldVersion      = "version"      // Name and version of the converter
+)
+
+// Version is the converter version written to headers. Release builds set
+// it with -ldflags "-X telegram_parse/internal/parser.Version=...".
+var Version = "1.0.0"
+
+// generatorName is the converter name written along with its version
+const generatorName = "telegram_parse"
+
+// localDateTimeFormat is an ISO 8601 date-time without offset, a local
+// date-time in both YAML and TOML front matter
+const localDateTimeFormat = "2006-01-02T15:04:05"
+
+// headerField is a key and value of a front matter block. Values are strings,
+// numbers, times, participant lists or lists of nested fields.
+type headerField struct {
+	Key   string
+	Value interface{}
+}
+
+// hasHeaderField reports whether an optional header field is enabled
+func (p *JSONToMarkdown) hasHeaderField(field string) bool {
+	return p.headerFields[field]
+}
+
+// hasFrontMatter reports whether the chat header is written as front matter
+func (p *JSONToMarkdown) hasFrontMatter() bool {
+	return p.headerStyle == HeaderYAML || p.headerStyle == HeaderTOML
+}
+
+// messageDates returns the dates of the first and the last message of a chat,
+// zero times if no message has a date
+func (p *JSONToMarkdown) messageDates(export *telegram.Export) (time.Time, time.Time) {
+	var first, last time.Time
+	for i := range export.Messages {
+		if export.Messages[i].Date == "" {
+			continue
+		}
+		date := p.parseDate(export.Messages[i].Date)
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if last.IsZero() || date.After(last) {
+			last = date
+		}
+	}
+	return first, last
+}
+
+// activeParticipants returns the members of a chat, most active first
+func (p *JSONToMarkdown) activeParticipants(export *telegram.Export) []*participant {
+	participants := p.chatParticipants(export)
+
+	// Members with equal counts keep the order of their first message
+	sort.SliceStable(participants, func(i, j int) bool {
+		return participants[i].Messages > participants[j].Messages
+	})
+	return participants
+}
+
+// participantName returns the name of a member, or its ID for deleted accounts
+func participantName(member *participant) string {
+	if member.Name != "" {
+		return member.Name
+	}
+	return member.FromID
+}
+
+// generator returns the name and version of the converter
+func generator() string {
+	return generatorName + " " + Version
+}
+
+// writeHeader writes the chat header as bold fields under the chat heading
+func (p *JSONToMarkdown) writeHeader(export *telegram.Export, result *strings.Builder) {
+	result.WriteString(fmt.Sprintf("# %s\n\n", export.Name))
+
+	lines := []string{fmt.Sprintf("**%s** %s", p.tr("Type:"), export.Type)}
+	if export.ID != 0 {
+		lines = append(lines, fmt.Sprintf("**ID:** %d", export.ID))
+	}
+	lines = append(lines, fmt.Sprintf("**%s** %d", p.tr("Messages:"), len(export.Messages)))
+
+	if p.hasHeaderField(HeaderFieldDates) {
+		if first, last := p.messageDates(export); !first.IsZero() {
+			lines = append(lines, fmt.Sprintf("**%s** %s — %s", p.tr("Period:"),
+				p.formatDate(first, p.dateFormat), p.formatDate(last, p.dateFormat)))
+		}
+	}
+
+	if p.hasHeaderField(HeaderFieldParticipants) {
+		var names []string
+		for _, member := range p.activeParticipants(export) {
+			names = append(names, fmt.Sprintf("%s (%d)", participantName(member), member.Messages))
+		}
+		if len(names) > 0 {
+			lines = append(lines, fmt.Sprintf("**%s** %s", p.tr("Participants:"), strings.Join(names, ", ")))
+		}
+	}
+
+	if p.hasHeaderField(HeaderFieldSource) && p.conv != nil && p.conv.source != "" {
+		lines = append(lines, fmt.Sprintf("**%s** %s", p.tr("Source:"), p.conv.source))
+	}
+	if p.hasHeaderField(HeaderFieldGenerated) && p.conv != nil {
+		lines = append(lines, fmt.Sprintf("**%s** %s", p.tr("Generated:"), p.formatDate(p.conv.generated, p.dateFormat)))
+	}
+	if p.hasHeaderField(HeaderFieldVersion) {
+		lines = append(lines, fmt.Sprintf("**%s** %s", p.tr("Generator:"), generator()))
+	}
+
+	result.WriteString(strings.Join(lines, "  \n") + "  \n\n")
+	result.WriteString("---\n\n")
+}
+
+// chatHeaderFields returns the front matter fields of a chat
+func (p *JSONToMarkdown) chatHeaderFields(export *telegram.Export) []headerField {
+	fields := []headerField{
+		{"chat", export.Name},
+		{"type", export.Type},
+	}
+	if export.ID != 0 {
+		fields = append(fields, headerField{"id", export.ID})
+	}
+	fields = append(fields, headerField{"messages", len(export.Messages)})
+
+	if p.hasHeaderField(HeaderFieldDates) {
+		if first, last := p.messageDates(export); !first.IsZero() {
+			fields = append(fields, headerField{"date_start", first}, headerField{"date_end", last})
+		}
+	}
+	if p.hasHeaderField(HeaderFieldParticipants) {
+		if participants := p.activeParticipants(export); len(participants) > 0 {
+			fields = append(fields, headerField{"participants", participants})
+		}
+	}
+
+	return fields
+}
+
+// headerFrontMatter returns the front matter of a Markdown file. Full account
+// exports list their chats under "chats".
+func (p *JSONToMarkdown) headerFrontMatter(exports []telegram.Export) string {
+	var fields []headerField
+	if len(exports) == 1 {
+		fields = p.chatHeaderFields(&exports[0])
+	} else {
+		chats := make([][]headerField, len(exports))
+		for i := range exports {
+			chats[i] = p.chatHeaderFields(&exports[i])
+		}
+		fields = append(fields, headerField{"chats", chats})
+	}
+
+	if p.hasHeaderField(HeaderFieldSource) && p.conv.source != "" {
+		fields = append(fields, headerField{"source", p.conv.source})
+	}
+	if p.hasHeaderField(HeaderFieldGenerated) {
+		fields = append(fields, headerField{"generated", p.conv.generated})
+	}
+	if p.hasHeaderField(HeaderFieldVersion) {
+		fields = append(fields, headerField{"generator", generator()})
+	}
+
+	var result strings.Builder
+	if p.headerStyle == HeaderTOML {
+		result.WriteString("+++\n")
+		writeTOMLFields(&result, fields)
+		result.WriteString("+++\n\n")
+	} else {
+		result.WriteString("---\n")
+		writeYAMLFields(&result, fields, "")
+		result.WriteString("---\n\n")
+	}
+	return result.String()
+}
+
+// frontMatterScalar formats a scalar value. Quoted JSON strings and local
+// date-times are valid in both YAML and TOML.
+func frontMatterScalar(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return yamlString(v)
+	case time.Time:
+		return v.Format(localDateTimeFormat)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeYAMLFields writes fields as YAML mappings with the given indentation
+func writeYAMLFields(result *strings.Builder, fields []headerField, indent string) {
+	for _, field := range fields {
+		switch value := field.Value.(type) {
+		case []*participant:
+			result.WriteString(indent + field.Key + ":\n")
+			for _, member := range value {
+				result.WriteString(fmt.Sprintf("%s  - name: %s\n%s    messages: %d\n",
+					indent, yamlString(participantName(member)), indent, member.Messages))
+			}
+		case [][]headerField:
+			result.WriteString(indent + field.Key + ":\n")
+			for _, item := range value {
+				// The first field of a nested mapping starts the list item
+				var nested strings.Builder
+				writeYAMLFields(&nested, item, indent+"    ")
+				result.WriteString(indent + "  - " + strings.TrimPrefix(nested.String(), indent+"    "))
+			}
+		default:
+			result.WriteString(fmt.Sprintf("%s%s: %s\n", indent, field.Key, frontMatterScalar(value)))
+		}
+	}
+}
+
+// writeTOMLFields writes fields as TOML keys. Lists of nested fields become
+// arrays of tables, which follow all other keys.
+func writeTOMLFields(result *strings.Builder, fields []headerField) {
+	var tables []headerField
+	for _, field := range fields {
+		switch value := field.Value.(type) {
+		case []*participant:
+			items := make([]string, len(value))
+			for i, member := range value {
+				items[i] = fmt.Sprintf("{ name = %s, messages = %d }", yamlString(participantName(member)), member.Messages)
+			}
+			result.WriteString(fmt.Sprintf("%s = [%s]\n", field.Key, strings.Join(items, ", ")))
+		case [][]headerField:
+			tables = append(tables, field)
+		default:
+			result.WriteString(fmt.Sprintf("%s = %s\n", field.Key, frontMatterScalar(value)))
+		}
+	}
+
+	for _, table := range tables {
+		for _, item := range table.Value.([][]headerField) {
+			result.WriteString("\n[[" + table.Key + "]]\n")
+			writeTOMLFields(result, item)
+		}
+	}
+}
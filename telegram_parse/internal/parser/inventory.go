@@ -0,0 +1,225 @@
+package parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"telegram_parse/internal/telegram"
+)
+
+// Media file statuses
+const (
+	MediaPresent     = "present"
+	MediaMissing     = "missing"
+	MediaNotIncluded = "not_included" // Export settings excluded the file
+)
+
+// mediaItem describes a media file referenced by a message
+type mediaItem struct {
+	Chat      string
+	MessageID int64
+	Type      string
+	Path      string
+	Size      int64
+	MimeType  string
+	Status    string
+}
+
+// isMediaPlaceholder detects placeholders written instead of a path, such as
+// "(File not included. Change data exporting settings to download.)"
+func isMediaPlaceholder(mediaPath string) bool {
+	return strings.HasPrefix(mediaPath, "(") && strings.HasSuffix(mediaPath, ")")
+}
+
+// mediaStatus checks whether a referenced media file exists in the export.
+// Missing files are recorded in the conversion report.
+func (p *JSONToMarkdown) mediaStatus(mediaPath string) string {
+	status, _ := p.statMedia(mediaPath)
+	if status == MediaMissing {
+		p.recordMissing(mediaPath)
+	}
+	return status
+}
+
+// statMedia returns the status and file info of a referenced media file
+func (p *JSONToMarkdown) statMedia(mediaPath string) (string, fs.FileInfo) {
+	if isMediaPlaceholder(mediaPath) {
+		return MediaNotIncluded, nil
+	}
+
+	if p.conv == nil {
+		return MediaPresent, nil
+	}
+
+	clean := path.Clean(filepath.ToSlash(mediaPath))
+	if !fs.ValidPath(clean) || clean == "." {
+		return MediaMissing, nil
+	}
+
+	info, err := fs.Stat(p.conv.fsys, path.Join(p.conv.baseDir, clean))
+	if err != nil {
+		return MediaMissing, nil
+	}
+
+	return MediaPresent, info
+}
+
+// collectMedia lists all media files referenced by messages of a chat
+func (p *JSONToMarkdown) collectMedia(export *telegram.Export) []mediaItem {
+	var items []mediaItem
+
+	add := func(msg *telegram.Message, mediaType, mediaPath, mimeType string) {
+		if mediaPath == "" {
+			return
+		}
+
+		status, info := p.statMedia(mediaPath)
+		item := mediaItem{
+			Chat:      export.Name,
+			MessageID: msg.ID,
+			Type:      mediaType,
+			Path:      mediaPath,
+			MimeType:  mimeType,
+			Status:    status,
+		}
+		if info != nil {
+			item.Size = info.Size()
+		}
+		if item.MimeType == "" && status != MediaNotIncluded {
+			item.MimeType = mime.TypeByExtension(path.Ext(filepath.ToSlash(mediaPath)))
+		}
+
+		items = append(items, item)
+	}
+
+	for i := range export.Messages {
+		msg := &export.Messages[i]
+
+		fileType := msg.MediaType
+		if fileType == "" {
+			fileType = "file"
+		}
+
+		add(msg, "photo", msg.Photo, "")
+		add(msg, fileType, msg.File, msg.MimeType)
+		add(msg, "thumbnail", msg.Thumbnail, "")
+	}
+
+	return items
+}
+
+// writeInventory adds the media inventory appendix of a chat
+func (p *JSONToMarkdown) writeInventory(items []mediaItem, result *strings.Builder) {
+	if len(items) == 0 {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[item.Status]++
+	}
+
+	result.WriteString("## " + p.tr("Media Inventory") + "\n\n")
+	result.WriteString(p.tr("**Files:** %d, present: %d, missing: %d, not included: %d",
+		len(items), counts[MediaPresent], counts[MediaMissing], counts[MediaNotIncluded]) + "\n\n")
+
+	result.WriteString(fmt.Sprintf("| %s | %s | %s | %s | MIME | %s |\n",
+		p.tr("Message"), p.tr("Type"), p.tr("Path"), p.tr("Size"), p.tr("Status")))
+	result.WriteString("|---|---|---|---|---|---|\n")
+
+	for _, item := range items {
+		size := ""
+		if item.Status == MediaPresent {
+			size = formatSize(item.Size)
+		}
+
+		status := "✅ " + p.tr("present")
+		switch item.Status {
+		case MediaMissing:
+			status = "❌ " + p.tr("missing")
+		case MediaNotIncluded:
+			status = "⚠️ " + p.tr("not included")
+		}
+
+		mediaPath := item.Path
+		if item.Status == MediaNotIncluded {
+			mediaPath = ""
+		}
+
+		result.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s | %s |\n",
+			item.MessageID, item.Type, strings.ReplaceAll(mediaPath, "|", "\\|"), size, item.MimeType, status))
+	}
+
+	result.WriteString("\n")
+}
+
+// inventoryPath returns the media inventory CSV path for an output file
+func inventoryPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "_media.csv"
+}
+
+// writeInventoryCSV writes the media inventory of all converted chats,
+// optionally starting with a BOM
+func writeInventoryCSV(csvPath string, items []mediaItem, bom bool) error {
+	file, err := os.Create(csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to create media inventory: %w", err)
+	}
+	defer file.Close()
+
+	if bom {
+		if _, err := file.WriteString(utf8BOM); err != nil {
+			return fmt.Errorf("failed to write media inventory: %w", err)
+		}
+	}
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"chat", "message_id", "type", "path", "size", "mime_type", "status"})
+
+	for _, item := range items {
+		size := ""
+		if item.Status == MediaPresent {
+			size = strconv.FormatInt(item.Size, 10)
+		}
+
+		writer.Write(csvRow([]string{
+			item.Chat,
+			strconv.FormatInt(item.MessageID, 10),
+			item.Type,
+			item.Path,
+			size,
+			item.MimeType,
+			item.Status,
+		}))
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write media inventory: %w", err)
+	}
+
+	return nil
+}
+
+// formatSize formats a file size in human readable form
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
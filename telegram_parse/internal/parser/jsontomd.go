@@ -3,51 +3,294 @@ package parser
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
+	"telegram_parse/internal/models"
 	"telegram_parse/internal/telegram"
 )
 
+// Layouts controlling how messages of a chat are arranged
+const (
+	LayoutChronological = "chronological" // Messages in export order
+	LayoutThreaded      = "threaded"      // Replies nested under the messages they answer
+	LayoutChannel       = "channel"       // Channel posts as article sections, other chats chronological
+)
+
+// Output formats
+const (
+	FormatMarkdown = "markdown" // Plain Markdown
+	FormatObsidian = "obsidian" // Markdown for an Obsidian vault with front matter and wikilinks
+	FormatHugo     = "hugo"     // Content tree for the Hugo static site generator
+	FormatJekyll   = "jekyll"   // Posts for the Jekyll static site generator
+	FormatJSONL    = "jsonl"    // One normalized JSON record per message
+	FormatCSV      = "csv"      // Messages and participants tables
+	FormatSQLite   = "sqlite"   // One SQLite database with full-text search for all chats
+	FormatEPUB     = "epub"     // E-book with a chapter per month
+	FormatOrg      = "org"      // Emacs Org-mode document
+	FormatAsciiDoc = "asciidoc" // AsciiDoc document
+	FormatText     = "text"     // Plain-text transcript, one line per message
+	FormatTemplate = "template" // User-defined Go template
+)
+
+// OutputExtension returns the file extension of the main output of a format.
+// CSV output is a directory named with a suffix instead.
+func OutputExtension(format string) string {
+	switch format {
+	case FormatJSONL:
+		return ".jsonl"
+	case FormatCSV:
+		return csvDirSuffix
+	case FormatEPUB:
+		return ".epub"
+	case FormatOrg:
+		return ".org"
+	case FormatAsciiDoc:
+		return ".adoc"
+	case FormatText:
+		return ".txt"
+	default:
+		return ".md"
+	}
+}
+
 // JSONToMarkdown converts Telegram JSON export to clean Markdown
 type JSONToMarkdown struct {
 	// Options for formatting
 	includeMetadata bool
+	headerStyle     string
+	headerFields    map[string]bool // Optional header fields
 	includeMedia    bool
 	dateFormat      string
+	mediaMode       string
+	embedImages     bool
+	inlineImageMax  int64 // Maximum size of images inlined as data URIs, 0 to disable
+	mediaInventory  bool
+	topicMode       string
+	layout          string
+	outputFormat    string
+	sitePages       string
+	csvBOM          bool
+	flavor          string
+	language        string    // Language of labels and sentences, empty for English
+	locale          *locale   // Translations of the language, nil for English
+	templatePath    string    // Template file of template output
+	database        *Database // Shared database for SQLite output
+	rootDir         string    // Root of Obsidian vaults and static sites, empty for the output directory
+	messageHeading  string    // Markdown heading of message headers
+
+	// State of the file being converted, set on a per-file copy
+	conv *conversion
+}
+
+// conversion holds state of a single file conversion
+type conversion struct {
+	fsys       fs.FS  // File system containing the export
+	baseDir    string // Directory of the JSON file within fsys
+	diskDir    string // Directory of the JSON file on disk, empty for archives
+	outputDir  string // Directory of the output file
+	assetsDir  string // Media directory relative to outputDir
+	linkPrefix string // Prefix of media links, "/" for site absolute links
+	stem       string // Output file name without extension
+	source     string // Path of the JSON file shown in headers
+	generated  time.Time
+	missing    []string
+	inventory  []mediaItem
+	people     map[string]person // Senders for Obsidian person notes
+	multiChat  bool              // File holds several chats of a full account export
+	anchorChat int64             // Chat ID in message anchors, set when multiChat
+
+	// Static site pages of the chat being written, for links between pages
+	siteSlug string
+	pageOf   map[int64]*sitePage // Page of each message
+	sitePage *sitePage           // Page being written
+
+	// Additional files written next to the output, such as forum topics
+	extraFiles []outputFile
+}
+
+// outputFile is an additional output file produced by a conversion
+type outputFile struct {
+	Path    string
+	Content string
+}
+
+// ConvertReport describes the result of a single file conversion
+type ConvertReport struct {
+	MissingMedia []string // Media paths referenced by messages but not found
+	OutputPath   string   // File written by the conversion, the chat index for static sites
 }
 
 // NewJSONToMarkdown creates a new JSON to Markdown converter
 func NewJSONToMarkdown() *JSONToMarkdown {
 	return &JSONToMarkdown{
 		includeMetadata: true,
+		headerStyle:     HeaderMarkdown,
 		includeMedia:    true,
 		dateFormat:      "2006-01-02 15:04:05",
+		mediaMode:       MediaModeReference,
+		topicMode:       TopicModeChronological,
+		layout:          LayoutChronological,
+		outputFormat:    FormatMarkdown,
+		sitePages:       SitePageMonth,
+		flavor:          FlavorGFM,
+		messageHeading:  "##",
 	}
 }
 
+// NewJSONToMarkdownWithOptions creates a converter configured from processing options
+func NewJSONToMarkdownWithOptions(options models.ProcessOptions) *JSONToMarkdown {
+	p := NewJSONToMarkdown()
+
+	if options.MediaMode != "" {
+		p.mediaMode = options.MediaMode
+	}
+	p.embedImages = options.EmbedImages
+	p.inlineImageMax = int64(options.InlineImageMaxKB) * 1024
+	p.mediaInventory = options.MediaInventory
+	if options.TopicMode != "" {
+		p.topicMode = options.TopicMode
+	}
+	if options.Layout != "" {
+		p.layout = options.Layout
+	}
+	if options.OutputFormat != "" {
+		p.outputFormat = options.OutputFormat
+	}
+	p.rootDir = options.OutputDir
+	p.csvBOM = options.CSVBOM
+	p.templatePath = options.TemplatePath
+	if options.MarkdownFlavor != "" {
+		p.flavor = options.MarkdownFlavor
+	}
+	if options.SitePages != "" {
+		p.sitePages = options.SitePages
+	}
+	if options.HeaderStyle != "" {
+		p.headerStyle = options.HeaderStyle
+	}
+	p.includeMetadata = p.headerStyle != HeaderNone
+	p.headerFields = make(map[string]bool)
+	for _, field := range options.HeaderFields {
+		p.headerFields[field] = true
+	}
+	if loc, ok := locales[options.Language]; ok {
+		p.language = options.Language
+		p.locale = loc
+		p.dateFormat = loc.dateFormat
+	}
+
+	// Static sites need media files under their static folder
+	if p.isSite() && p.mediaMode == MediaModeReference {
+		p.mediaMode = MediaModeCopy
+	}
+
+	return p
+}
+
 // ConvertFile converts JSON file to Markdown
-func (p *JSONToMarkdown) ConvertFile(inputPath, outputPath string) error {
+func (p *JSONToMarkdown) ConvertFile(inputPath, outputPath string) (*ConvertReport, error) {
+	dir := filepath.Dir(inputPath)
+	return p.convert(os.DirFS(dir), filepath.Base(inputPath), dir, inputPath, outputPath)
+}
+
+// ConvertFS converts a JSON file stored in a file system (a directory or a
+// ZIP archive) to Markdown. The source path is shown in headers.
+func (p *JSONToMarkdown) ConvertFS(fsys fs.FS, name, sourcePath, outputPath string) (*ConvertReport, error) {
+	return p.convert(fsys, name, "", sourcePath, outputPath)
+}
+
+// convert converts a JSON file using a per-file copy of the converter
+func (p *JSONToMarkdown) convert(fsys fs.FS, name, diskDir, sourcePath, outputPath string) (*ConvertReport, error) {
 	// Open input file
-	file, err := os.Open(inputPath)
+	file, err := fsys.Open(name)
 	if err != nil {
-		return fmt.Errorf("failed to open input file: %w", err)
+		return nil, fmt.Errorf("failed to open input file: %w", err)
 	}
 	defer file.Close()
 
 	// Parse JSON
-	var export telegram.Export
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&export)
+	exports, err := p.decodeExports(file)
+	if err != nil {
+		return nil, err
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+	c := *p
+	c.conv = &conversion{
+		fsys:      fsys,
+		baseDir:   path.Dir(name),
+		diskDir:   diskDir,
+		outputDir: filepath.Dir(outputPath),
+		assetsDir: path.Join("assets", stem),
+		stem:      stem,
+		source:    sourcePath,
+		generated: time.Now(),
+		people:    make(map[string]person),
+		multiChat: len(exports) > 1,
+	}
+
+	// Output directory is needed before media files are copied
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Static sites are written as a directory tree instead of a single file
+	written := outputPath
+	switch {
+	case c.isSite():
+		written, err = c.convertSite(exports)
+	case c.outputFormat == FormatJSONL:
+		err = c.writeJSONL(exports, outputPath)
+	case c.outputFormat == FormatCSV:
+		err = c.writeCSV(exports, outputPath)
+	case c.outputFormat == FormatSQLite:
+		err = c.writeDatabase(exports)
+	case c.outputFormat == FormatEPUB:
+		err = c.writeEPUB(exports, outputPath)
+	case c.outputFormat == FormatTemplate:
+		err = c.writeTemplate(exports, path.Base(name), outputPath)
+	case c.outputFormat == FormatText:
+		err = c.writeTranscript(exports, outputPath)
+	case c.markupSyntax() != nil:
+		err = c.writeMarkup(exports, outputPath)
+	default:
+		err = c.writeMarkdown(exports, outputPath)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to parse JSON: %w", err)
+		return nil, err
+	}
+
+	// Write media inventory next to the output. The inventory of a shared
+	// database is written once by the caller after the run.
+	if c.mediaInventory && c.database == nil {
+		if err := writeInventoryCSV(inventoryPath(outputPath), c.conv.inventory, c.csvBOM); err != nil {
+			return nil, err
+		}
 	}
 
-	// Convert to Markdown
-	markdown := p.exportToMarkdown(&export)
+	return &ConvertReport{MissingMedia: c.conv.missing, OutputPath: written}, nil
+}
+
+// writeMarkdown writes converted chats to the output file along with topic
+// files and person notes
+func (p *JSONToMarkdown) writeMarkdown(exports []telegram.Export, outputPath string) error {
+	// Convert to Markdown, one section per chat for full account exports
+	var parts []string
+	for i := range exports {
+		parts = append(parts, p.exportToMarkdown(&exports[i]))
+	}
+	markdown := strings.Join(parts, "\n")
+	if p.isObsidian() {
+		markdown = p.frontMatter(exports) + markdown
+	} else if p.hasFrontMatter() {
+		markdown = p.headerFrontMatter(exports) + markdown
+	}
 
 	// Write to output file
 	outFile, err := os.Create(outputPath)
@@ -63,34 +306,124 @@ func (p *JSONToMarkdown) ConvertFile(inputPath, outputPath string) error {
 		return fmt.Errorf("failed to write output: %w", err)
 	}
 
+	// Write topic files next to the output
+	for _, extra := range p.conv.extraFiles {
+		if err := os.WriteFile(extra.Path, []byte(extra.Content), 0644); err != nil {
+			return fmt.Errorf("failed to write topic file: %w", err)
+		}
+	}
+
+	// Create notes for senders linked from the vault
+	if p.isObsidian() {
+		if err := writePeopleNotes(p.outputRoot(), p.conv.people); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// outputRoot returns the root directory of vaults and sites
+func (p *JSONToMarkdown) outputRoot() string {
+	if p.rootDir != "" {
+		return p.rootDir
+	}
+	return p.conv.outputDir
+}
+
+// decodeExports parses a single chat export or all chats of a full account export
+func (p *JSONToMarkdown) decodeExports(r io.Reader) ([]telegram.Export, error) {
+	var data struct {
+		telegram.Export
+		telegram.AccountExport
+	}
+
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	if data.Chats == nil && data.LeftChats == nil {
+		return []telegram.Export{data.Export}, nil
+	}
+
+	var exports []telegram.Export
+	if data.Chats != nil {
+		exports = append(exports, data.Chats.List...)
+	}
+	if data.LeftChats != nil {
+		exports = append(exports, data.LeftChats.List...)
+	}
+
+	return exports, nil
+}
+
 // exportToMarkdown converts Export struct to Markdown
 func (p *JSONToMarkdown) exportToMarkdown(export *telegram.Export) string {
 	var result strings.Builder
+	p.scopeAnchors(export)
 
 	// Add header with chat information
-	if p.includeMetadata {
+	switch {
+	case !p.includeMetadata:
+	case p.hasFrontMatter() || p.isObsidian():
+		// Fields are written to the front matter of the file
 		result.WriteString(fmt.Sprintf("# %s\n\n", export.Name))
-		result.WriteString(fmt.Sprintf("**Type:** %s  \n", export.Type))
-		if export.ID != 0 {
-			result.WriteString(fmt.Sprintf("**ID:** %d  \n", export.ID))
+	default:
+		p.writeHeader(export, &result)
+	}
+
+	// Channel layout only applies to broadcast channels
+	if p.layout == LayoutChannel && !isChannel(export.Type) {
+		chronological := *p
+		chronological.layout = LayoutChronological
+		p = &chronological
+	}
+
+	// Process messages, grouped by topic for forums if enabled
+	var topics []*topic
+	if p.topicMode != TopicModeChronological {
+		topics = buildTopics(export)
+	}
+
+	if len(topics) > 0 {
+		p.topicsToMarkdown(export, topics, &result)
+	} else {
+		messages := make([]*telegram.Message, len(export.Messages))
+		for i := range export.Messages {
+			messages[i] = &export.Messages[i]
 		}
-		result.WriteString(fmt.Sprintf("**Messages:** %d  \n\n", len(export.Messages)))
-		result.WriteString("---\n\n")
+		p.writeMessages(messages, &result)
+	}
+
+	// Add media inventory appendix
+	if p.mediaInventory && p.conv != nil {
+		items := p.collectMedia(export)
+		p.conv.inventory = append(p.conv.inventory, items...)
+		p.writeInventory(items, &result)
+	}
+
+	return result.String()
+}
+
+// writeMessages converts a list of messages to Markdown
+func (p *JSONToMarkdown) writeMessages(messages []*telegram.Message, result *strings.Builder) {
+	switch p.layout {
+	case LayoutThreaded:
+		p.writeThreads(buildThreads(messages), 0, result)
+		return
+	case LayoutChannel:
+		p.writePosts(messages, result)
+		return
 	}
 
-	// Process messages
-	for _, message := range export.Messages {
-		messageMarkdown := p.messageToMarkdown(&message)
+	for _, message := range messages {
+		messageMarkdown := p.messageToMarkdown(message)
 		if messageMarkdown != "" {
 			result.WriteString(messageMarkdown)
 			result.WriteString("\n")
 		}
 	}
-
-	return result.String()
 }
 
 // messageToMarkdown converts a single message to Markdown
@@ -102,21 +435,19 @@ func (p *JSONToMarkdown) messageToMarkdown(msg *telegram.Message) string {
 		return ""
 	}
 
-	// Add message header
-	result.WriteString("## ")
-
-	// Add date
+	// Add message header with date and sender
+	title := ""
 	if msg.Date != "" {
-		parsedDate := p.parseDate(msg.Date)
-		result.WriteString(parsedDate.Format(p.dateFormat))
+		title = p.displayDate(msg.Date)
 	}
-
-	// Add sender
 	if msg.From != "" {
-		result.WriteString(fmt.Sprintf(" - %s", msg.From))
+		if p.isObsidian() {
+			title += " - " + wikilink(msg.From)
+		} else {
+			title += fmt.Sprintf(" - %s", msg.From)
+		}
 	}
-
-	result.WriteString("\n\n")
+	result.WriteString(p.messageHeader(title, msg.ID))
 
 	// Handle different message types
 	switch msg.Type {
@@ -128,25 +459,84 @@ func (p *JSONToMarkdown) messageToMarkdown(msg *telegram.Message) string {
 		p.processRegularMessage(msg, &result)
 	}
 
+	// Add inline keyboard buttons
+	if len(msg.InlineBotButtons) > 0 {
+		p.processButtons(msg.InlineBotButtons, &result)
+	}
+
+	// Add reactions
+	if len(msg.Reactions) > 0 {
+		p.processReactions(msg.Reactions, &result)
+	}
+
+	// Add self-destruct information
+	if msg.SelfDestructPeriod > 0 {
+		result.WriteString("\n⏱️ *" + p.tr("Self-destructing media (%s)", p.plural(msg.SelfDestructPeriod, "second")) + "*\n")
+	}
+
+	// Add channel post author
+	if msg.Author != "" {
+		result.WriteString("\n*" + p.tr("Author: %s", msg.Author) + "*\n")
+	}
+
 	// Add forwarded information
 	if msg.ForwardedFrom != "" {
-		result.WriteString(fmt.Sprintf("\n*Forwarded from: %s*\n", msg.ForwardedFrom))
+		result.WriteString("\n*" + p.tr("Forwarded from: %s", msg.ForwardedFrom) + "*\n")
+	} else if msg.ForwardedFromID != "" {
+		result.WriteString("\n*" + p.tr("Forwarded from: %s", msg.ForwardedFromID) + "*\n")
+	}
+
+	// Add saved from information
+	if msg.SavedFrom != "" {
+		result.WriteString("\n*" + p.tr("Saved from: %s", msg.SavedFrom) + "*\n")
 	}
 
 	// Add reply information
 	if msg.ReplyToMessageID != 0 {
-		result.WriteString(fmt.Sprintf("\n*Reply to message ID: %d*\n", msg.ReplyToMessageID))
+		if msg.ReplyToPeerID != "" {
+			result.WriteString("\n*" + p.tr("Reply to message ID: %d in chat %s", msg.ReplyToMessageID, msg.ReplyToPeerID) + "*\n")
+		} else {
+			result.WriteString("\n*" + p.flavorMessageLinks(p.tr("Reply to %s", p.messageLink(msg.ReplyToMessageID, p.tr("message ID:")))) + "*\n")
+		}
 	}
 
 	// Add via bot information
 	if msg.ViaBot != "" {
-		result.WriteString(fmt.Sprintf("\n*Via bot: %s*\n", msg.ViaBot))
+		result.WriteString("\n*" + p.tr("Via bot: %s", msg.ViaBot) + "*\n")
+	}
+
+	// Add edit information
+	if msg.Edited != "" {
+		result.WriteString("\n*" + p.tr("(edited at %s)", p.displayDate(msg.Edited)) + "*\n")
 	}
 
 	result.WriteString("\n---\n\n")
 	return result.String()
 }
 
+// messageHeader returns the heading of a message with the anchor linked from
+// replies and pins
+func (p *JSONToMarkdown) messageHeader(title string, id int64) string {
+	if id == 0 {
+		return p.anchoredHeading(p.messageHeading, title, "")
+	}
+	return p.anchoredHeading(p.messageHeading, title, p.chatAnchor(id))
+}
+
+// anchoredHeading returns a heading with an anchor. Hugo drops raw HTML by
+// default, so Hugo headings carry the anchor as a heading ID instead.
+// Telegram MarkdownV2 has no HTML and gets no anchor.
+func (p *JSONToMarkdown) anchoredHeading(heading, title, anchor string) string {
+	switch {
+	case anchor == "" || p.flavor == FlavorTelegram:
+		return fmt.Sprintf("%s %s\n\n", heading, title)
+	case p.outputFormat == FormatHugo:
+		return fmt.Sprintf("%s %s {#%s}\n\n", heading, title, anchor)
+	default:
+		return fmt.Sprintf("<a id=\"%s\"></a>\n\n%s %s\n\n", anchor, heading, title)
+	}
+}
+
 // processRegularMessage processes regular text messages
 func (p *JSONToMarkdown) processRegularMessage(msg *telegram.Message, result *strings.Builder) {
 	// Process text content
@@ -181,36 +571,80 @@ func (p *JSONToMarkdown) processRegularMessage(msg *telegram.Message, result *st
 
 // processServiceMessage processes service messages (join, leave, etc.)
 func (p *JSONToMarkdown) processServiceMessage(msg *telegram.Message, result *strings.Builder) {
-	if msg.Action != "" {
-		result.WriteString(fmt.Sprintf("*%s*", msg.Action))
+	if msg.Action == "" {
+		return
+	}
 
-		if msg.Actor != "" {
-			result.WriteString(fmt.Sprintf(" by %s", msg.Actor))
-		}
+	if sentence := p.describeAction(msg); sentence != "" {
+		result.WriteString(fmt.Sprintf("*%s*\n\n", p.flavorMessageLinks(sentence)))
 
-		if len(msg.Members) > 0 {
-			result.WriteString(fmt.Sprintf(" - Members: %s", strings.Join(msg.Members, ", ")))
+		// Show the new group photo
+		if msg.Action == "edit_group_photo" && p.includeMedia {
+			p.processMedia(msg, result)
 		}
 
-		if msg.Inviter != "" {
-			result.WriteString(fmt.Sprintf(" - Invited by: %s", msg.Inviter))
+		// Text sent from a web app
+		if msg.Action == "send_webview_data" && msg.Text != nil {
+			if textContent := p.extractTextContent(msg.Text, msg.TextEntities); textContent != "" {
+				result.WriteString(textContent)
+				result.WriteString("\n\n")
+			}
 		}
+		return
+	}
 
-		if msg.Title != "" {
-			result.WriteString(fmt.Sprintf(" - Title: %s", msg.Title))
-		}
+	// Unknown actions are rendered generically
+	result.WriteString(fmt.Sprintf("*%s*", msg.Action))
 
-		result.WriteString("\n\n")
+	if msg.Actor != "" {
+		result.WriteString(" " + p.tr("by %s", msg.Actor))
 	}
+
+	if len(msg.Members) > 0 {
+		result.WriteString(" - " + p.tr("Members: %s", strings.Join(msg.Members, ", ")))
+	}
+
+	if msg.Inviter != "" {
+		result.WriteString(" - " + p.tr("Invited by: %s", msg.Inviter))
+	}
+
+	if msg.Title != "" {
+		result.WriteString(" - " + p.tr("Title: %s", msg.Title))
+	}
+
+	result.WriteString("\n\n")
 }
 
 // extractTextContent processes text content with entities
 func (p *JSONToMarkdown) extractTextContent(text interface{}, entities []telegram.TextEntity) string {
 	switch t := text.(type) {
 	case string:
-		return p.cleanText(t)
+		return strings.TrimSpace(p.cleanText(t))
 	case []interface{}:
-		return p.processTextEntities(t, entities)
+		return strings.TrimSpace(p.processTextEntities(t, entities))
+	default:
+		return ""
+	}
+}
+
+// plainText returns message text without formatting
+func plainText(text interface{}) string {
+	switch t := text.(type) {
+	case string:
+		return t
+	case []interface{}:
+		var result strings.Builder
+		for _, item := range t {
+			switch v := item.(type) {
+			case string:
+				result.WriteString(v)
+			case map[string]interface{}:
+				if textVal, ok := v["text"].(string); ok {
+					result.WriteString(textVal)
+				}
+			}
+		}
+		return result.String()
 	default:
 		return ""
 	}
@@ -239,34 +673,51 @@ func (p *JSONToMarkdown) processTextEntities(textArray []interface{}, entities [
 	return result.String()
 }
 
-// formatText applies Markdown formatting based on entity type
+// formatText applies Markdown formatting of the flavor based on entity type
 func (p *JSONToMarkdown) formatText(text, entityType string, entity map[string]interface{}) string {
 	cleanedText := p.cleanText(text)
 
 	switch entityType {
 	case "bold":
-		return fmt.Sprintf("**%s**", cleanedText)
+		if p.flavor == FlavorTelegram {
+			return wrapMarker(cleanedText, "*", "*")
+		}
+		return wrapMarker(cleanedText, "**", "**")
 	case "italic":
-		return fmt.Sprintf("*%s*", cleanedText)
+		if p.flavor == FlavorTelegram {
+			return wrapMarker(cleanedText, "_", "_")
+		}
+		return wrapMarker(cleanedText, "*", "*")
 	case "code":
-		return fmt.Sprintf("`%s`", cleanedText)
+		return p.codeSpan(text)
 	case "pre":
-		return fmt.Sprintf("```\n%s\n```", cleanedText)
+		language, _ := entity["language"].(string)
+		return p.codeBlock(text, language)
 	case "text_link":
 		if href, ok := entity["href"].(string); ok {
-			return fmt.Sprintf("[%s](%s)", cleanedText, href)
+			return p.inlineLink(cleanedText, href)
 		}
 		return cleanedText
-	case "mention":
-		return fmt.Sprintf("@%s", cleanedText)
+	case "link", "email":
+		return p.autoLink(strings.TrimSpace(text))
 	case "hashtag":
-		return fmt.Sprintf("#%s", cleanedText)
+		if p.isObsidian() {
+			if tag := hashtagTerm(text); tag != "" {
+				return "#" + tag
+			}
+		}
+		// Hashtags, mentions and cashtags already start with their sign
+		return cleanedText
 	case "strikethrough":
-		return fmt.Sprintf("~~%s~~", cleanedText)
+		return p.strikethrough(cleanedText)
 	case "underline":
-		return fmt.Sprintf("__%s__", cleanedText)
+		return p.underline(cleanedText)
 	case "spoiler":
-		return fmt.Sprintf("||%s||", cleanedText)
+		return p.spoiler(cleanedText)
+	case "custom_emoji":
+		return p.customEmoji(text, entity)
+	case "blockquote", "expandable_blockquote":
+		return p.blockquote(cleanedText)
 	default:
 		return cleanedText
 	}
@@ -275,67 +726,252 @@ func (p *JSONToMarkdown) formatText(text, entityType string, entity map[string]i
 // processMedia adds media information to markdown
 func (p *JSONToMarkdown) processMedia(msg *telegram.Message, result *strings.Builder) {
 	if msg.Photo != "" {
-		result.WriteString(fmt.Sprintf("📷 **Photo:** %s", filepath.Base(msg.Photo)))
-		if msg.Width > 0 && msg.Height > 0 {
-			result.WriteString(fmt.Sprintf(" (%dx%d)", msg.Width, msg.Height))
+		p.processPhoto(msg, result)
+	}
+
+	if msg.File != "" || (msg.MediaType != "" && msg.MediaType != "photo") {
+		p.processFile(msg, result)
+	}
+}
+
+// processPhoto adds a photo as an image or a file name
+func (p *JSONToMarkdown) processPhoto(msg *telegram.Message, result *strings.Builder) {
+	switch p.mediaStatus(msg.Photo) {
+	case MediaNotIncluded:
+		result.WriteString(fmt.Sprintf("📷 **%s** *%s*\n\n", p.tr("Photo:"), p.tr("not included in the export")))
+		return
+	case MediaMissing:
+		result.WriteString(fmt.Sprintf("📷 **%s** %s *(%s)*\n\n", p.tr("Photo:"), filepath.Base(msg.Photo), p.tr("missing")))
+		return
+	}
+
+	name := filepath.Base(msg.Photo)
+	if p.embedImages {
+		if src, ok := p.imageSource(msg.Photo); ok {
+			result.WriteString(p.imageMarkdown(name, src, msg.Width, msg.Height) + "\n\n")
+			return
 		}
-		result.WriteString("\n\n")
 	}
 
+	if !p.copiesMedia() {
+		result.WriteString(fmt.Sprintf("📷 **%s** %s", p.tr("Photo:"), name))
+	} else if link, ok := p.relinkMedia(msg.Photo); ok && p.isObsidian() {
+		result.WriteString("📷 " + p.obsidianEmbed(msg.Photo))
+	} else if ok {
+		result.WriteString(fmt.Sprintf("📷 ![%s](%s)", name, link))
+	} else {
+		result.WriteString(fmt.Sprintf("📷 **%s** %s *(%s)*", p.tr("Photo:"), name, p.tr("missing")))
+	}
+	if msg.Width > 0 && msg.Height > 0 {
+		result.WriteString(fmt.Sprintf(" (%dx%d)", msg.Width, msg.Height))
+	}
+	result.WriteString("\n\n")
+}
+
+// processFile adds a file with a dedicated rendering for each media type
+func (p *JSONToMarkdown) processFile(msg *telegram.Message, result *strings.Builder) {
+	if msg.MediaType == "sticker" {
+		p.processSticker(msg, result)
+		return
+	}
+
+	emoji, label := p.mediaLabel(msg.MediaType)
+	result.WriteString(fmt.Sprintf("%s **%s:**", emoji, label))
+
+	name := msg.FileName
+	if name == "" && msg.File != "" && !isMediaPlaceholder(msg.File) {
+		name = filepath.Base(msg.File)
+	}
+
+	// Audio tracks are titled "performer – title" when known
+	text := name
+	titled := false
+	if msg.MediaType == "audio_file" && (msg.Performer != "" || msg.Title != "") {
+		text = strings.Trim(msg.Performer+" – "+msg.Title, " –")
+		titled = true
+	}
+
+	fileLink := ""
 	if msg.File != "" {
-		result.WriteString(fmt.Sprintf("📎 **File:** %s", filepath.Base(msg.File)))
-		if msg.MimeType != "" {
-			result.WriteString(fmt.Sprintf(" (%s)", msg.MimeType))
+		switch status := p.mediaStatus(msg.File); {
+		case status == MediaNotIncluded && titled:
+			result.WriteString(fmt.Sprintf(" %s *(%s)*", text, p.tr("not included in the export")))
+		case status == MediaNotIncluded:
+			result.WriteString(" *" + p.tr("not included in the export") + "*")
+		case status == MediaMissing:
+			result.WriteString(fmt.Sprintf(" %s *(%s)*", text, p.tr("missing")))
+		case !p.copiesMedia():
+			result.WriteString(" " + text)
+		default:
+			if link, ok := p.relinkMedia(msg.File); ok {
+				fileLink = link
+				if p.isObsidian() {
+					result.WriteString(" " + text)
+				} else {
+					result.WriteString(fmt.Sprintf(" [%s](%s)", text, link))
+				}
+			} else {
+				result.WriteString(fmt.Sprintf(" %s *(%s)*", text, p.tr("missing")))
+			}
+		}
+	} else if text != "" {
+		result.WriteString(" " + text)
+	}
+
+	// Duration, size and type details
+	var details []string
+	if duration := mediaDuration(msg); duration > 0 {
+		details = append(details, formatDuration(duration))
+	}
+	if msg.FileSize > 0 {
+		details = append(details, formatSize(msg.FileSize))
+	}
+	if msg.MimeType != "" && (msg.MediaType == "" || label == p.tr("Media")) {
+		details = append(details, msg.MimeType)
+	}
+	if len(details) > 0 {
+		result.WriteString(fmt.Sprintf(" (%s)", strings.Join(details, ", ")))
+	}
+	result.WriteString("\n\n")
+
+	// Obsidian embeds copied files, which also shows players for audio and video
+	if p.isObsidian() && fileLink != "" {
+		result.WriteString(p.obsidianEmbed(msg.File) + "\n\n")
+		return
+	}
+
+	// Thumbnail preview linking to the file itself
+	if msg.Thumbnail != "" && p.copiesMedia() && p.mediaStatus(msg.Thumbnail) == MediaPresent {
+		if thumbLink, ok := p.relinkMedia(msg.Thumbnail); ok {
+			target := fileLink
+			if target == "" {
+				target = thumbLink
+			}
+			result.WriteString(fmt.Sprintf("[![%s](%s)](%s)\n\n", name, thumbLink, target))
 		}
-		if msg.Duration > 0 {
-			result.WriteString(fmt.Sprintf(" - Duration: %d seconds", msg.Duration))
+	}
+}
+
+// processSticker adds a sticker as its emoji or as an image
+func (p *JSONToMarkdown) processSticker(msg *telegram.Message, result *strings.Builder) {
+	if p.embedImages && p.processStickerImage(msg, result) {
+		return
+	}
+
+	if msg.StickerEmoji != "" {
+		result.WriteString(fmt.Sprintf("%s *(%s)*\n\n", msg.StickerEmoji, p.tr("sticker")))
+		return
+	}
+
+	result.WriteString("😀 *" + p.tr("Sticker") + "*\n\n")
+}
+
+// mediaLabel returns emoji and label for a media type
+func (p *JSONToMarkdown) mediaLabel(mediaType string) (string, string) {
+	switch mediaType {
+	case "":
+		return "📎", p.tr("File")
+	case "voice_message":
+		return "🎤", p.tr("Voice message")
+	case "video_message":
+		return "📹", p.tr("Video message")
+	case "video_file":
+		return "🎬", p.tr("Video")
+	case "audio_file":
+		return "🎵", p.tr("Audio")
+	case "animation":
+		return "🎞️", "GIF"
+	default:
+		return "🎬", p.tr("Media")
+	}
+}
+
+// mediaDuration returns media duration in seconds. Newer exports use
+// duration_seconds, older ones duration.
+func mediaDuration(msg *telegram.Message) int {
+	if msg.DurationSeconds > 0 {
+		return msg.DurationSeconds
+	}
+	return msg.Duration
+}
+
+// formatDuration formats seconds as m:ss or h:mm:ss
+func formatDuration(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// processReactions adds a line with reaction emoji and counts
+func (p *JSONToMarkdown) processReactions(reactions []telegram.Reaction, result *strings.Builder) {
+	var parts []string
+	for _, reaction := range reactions {
+		switch {
+		case reaction.Emoji != "":
+			parts = append(parts, fmt.Sprintf("%s %d", reaction.Emoji, reaction.Count))
+		case reaction.Type == "paid":
+			parts = append(parts, fmt.Sprintf("⭐ %d", reaction.Count))
+		default:
+			parts = append(parts, fmt.Sprintf("🧩 %d", reaction.Count))
 		}
-		result.WriteString("\n\n")
 	}
 
-	if msg.MediaType != "" && msg.MediaType != "photo" {
-		result.WriteString(fmt.Sprintf("🎬 **Media Type:** %s\n\n", msg.MediaType))
+	result.WriteString(fmt.Sprintf("\n**%s** %s\n", p.tr("Reactions:"), strings.Join(parts, " · ")))
+}
+
+// processButtons adds inline keyboard buttons as a list of links
+func (p *JSONToMarkdown) processButtons(rows [][]telegram.Button, result *strings.Builder) {
+	result.WriteString("\n**" + p.tr("Buttons:") + "**\n")
+	for _, row := range rows {
+		for _, button := range row {
+			if button.Type == "url" && button.Data != "" {
+				result.WriteString(fmt.Sprintf("- [%s](%s)\n", button.Text, button.Data))
+			} else {
+				result.WriteString(fmt.Sprintf("- %s\n", button.Text))
+			}
+		}
 	}
 }
 
 // processPoll adds poll information to markdown
 func (p *JSONToMarkdown) processPoll(poll *telegram.Poll, result *strings.Builder) {
-	result.WriteString("📊 **Poll**\n\n")
-	result.WriteString(fmt.Sprintf("**Question:** %s\n\n", poll.Question))
+	result.WriteString("📊 **" + p.tr("Poll") + "**\n\n")
+	result.WriteString(fmt.Sprintf("**%s** %s\n\n", p.tr("Question:"), poll.Question))
 
 	if len(poll.Answers) > 0 {
-		result.WriteString("**Options:**\n")
+		result.WriteString("**" + p.tr("Options:") + "**\n")
 		for _, answer := range poll.Answers {
 			marker := "☐"
 			if answer.Chosen {
 				marker = "☑"
 			}
-			result.WriteString(fmt.Sprintf("- %s %s (%d votes)\n", marker, answer.Text, answer.Voters))
+			result.WriteString(fmt.Sprintf("- %s %s (%s)\n", marker, answer.Text, p.plural(answer.Voters, "vote")))
 		}
 		result.WriteString("\n")
 	}
 
 	if poll.Closed {
-		result.WriteString("*Poll is closed*\n")
+		result.WriteString("*" + p.tr("Poll is closed") + "*\n")
 	}
 
-	result.WriteString(fmt.Sprintf("**Total voters:** %d\n\n", poll.TotalVoters))
+	result.WriteString(fmt.Sprintf("**%s** %d\n\n", p.tr("Total voters:"), poll.TotalVoters))
 }
 
 // processContact adds contact information to markdown
 func (p *JSONToMarkdown) processContact(contact *telegram.Contact, result *strings.Builder) {
-	result.WriteString("📞 **Contact**\n\n")
+	result.WriteString("📞 **" + p.tr("Contact") + "**\n\n")
 
 	if contact.FirstName != "" || contact.LastName != "" {
-		result.WriteString(fmt.Sprintf("**Name:** %s %s\n", contact.FirstName, contact.LastName))
+		result.WriteString(fmt.Sprintf("**%s** %s %s\n", p.tr("Name:"), contact.FirstName, contact.LastName))
 	}
 
 	if contact.PhoneNumber != "" {
-		result.WriteString(fmt.Sprintf("**Phone:** %s\n", contact.PhoneNumber))
+		result.WriteString(fmt.Sprintf("**%s** %s\n", p.tr("Phone:"), contact.PhoneNumber))
 	}
 
 	if contact.UserID != 0 {
-		result.WriteString(fmt.Sprintf("**User ID:** %d\n", contact.UserID))
+		result.WriteString(fmt.Sprintf("**%s** %d\n", p.tr("User ID:"), contact.UserID))
 	}
 
 	result.WriteString("\n")
@@ -343,9 +979,9 @@ func (p *JSONToMarkdown) processContact(contact *telegram.Contact, result *strin
 
 // processLocation adds location information to markdown
 func (p *JSONToMarkdown) processLocation(location *telegram.Location, result *strings.Builder) {
-	result.WriteString("📍 **Location**\n\n")
-	result.WriteString(fmt.Sprintf("**Coordinates:** %.6f, %.6f\n", location.Latitude, location.Longitude))
-	result.WriteString(fmt.Sprintf("**Map Link:** https://maps.google.com/?q=%.6f,%.6f\n\n", location.Latitude, location.Longitude))
+	result.WriteString("📍 **" + p.tr("Location") + "**\n\n")
+	result.WriteString(fmt.Sprintf("**%s** %.6f, %.6f\n", p.tr("Coordinates:"), location.Latitude, location.Longitude))
+	result.WriteString(fmt.Sprintf("**%s** https://maps.google.com/?q=%.6f,%.6f\n\n", p.tr("Map Link:"), location.Latitude, location.Longitude))
 }
 
 // parseDate parses Telegram date format
@@ -364,21 +1000,9 @@ func (p *JSONToMarkdown) parseDate(dateStr string) time.Time {
 	return time.Now()
 }
 
-// cleanText cleans text content, removing unwanted characters
+// cleanText collapses whitespace and escapes characters with a meaning in
+// the Markdown flavor. Fragments are not trimmed, since spaces between
+// entities belong to them.
 func (p *JSONToMarkdown) cleanText(text string) string {
-	// Remove excessive whitespace
-	cleaned := regexp.MustCompile(`\s+`).ReplaceAllString(text, " ")
-	cleaned = strings.TrimSpace(cleaned)
-
-	// Escape markdown special characters that shouldn't be interpreted
-	specialChars := []string{
-		"\\", "`", "*", "_", "{", "}", "[", "]", "(", ")",
-		"#", "+", "-", ".", "!", "|",
-	}
-
-	for _, char := range specialChars {
-		cleaned = strings.ReplaceAll(cleaned, char, "\\"+char)
-	}
-
-	return cleaned
+	return p.escapeText(whitespacePattern.ReplaceAllString(text, " "))
 }
@@ -0,0 +1,263 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Output languages of labels, sentences and dates
+const (
+	LanguageEnglish = "en"
+	LanguageRussian = "ru"
+)
+
+// locale holds the translations of an output language. English is the
+// source language and has no locale.
+type locale struct {
+	dateFormat string
+	strings    map[string]string   // Translations keyed by the English format string
+	units      map[string][]string // Plural forms keyed by the English unit name
+	months     [12]string          // Month names standing alone, as in "January 2006"
+	monthsOf   [12]string          // Month names in dates, as in "2 January 2006"
+	pluralForm func(n int) int     // Index of the plural form for a count
+}
+
+// locales lists translations by language code
+var locales = map[string]*locale{
+	LanguageRussian: russianLocale,
+}
+
+// russianLocale translates output into Russian
+var russianLocale = &locale{
+	dateFormat: "02.01.2006 15:04:05",
+	months: [12]string{
+		"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+		"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+	},
+	monthsOf: [12]string{
+		"января", "февраля", "марта", "апреля", "мая", "июня",
+		"июля", "августа", "сентября", "октября", "ноября", "декабря",
+	},
+	pluralForm: russianPluralForm,
+	units: map[string][]string{
+		"second":  {"секунда", "секунды", "секунд"},
+		"minute":  {"минута", "минуты", "минут"},
+		"hour":    {"час", "часа", "часов"},
+		"day":     {"день", "дня", "дней"},
+		"week":    {"неделя", "недели", "недель"},
+		"month":   {"месяц", "месяца", "месяцев"},
+		"time":    {"раз", "раза", "раз"},
+		"message": {"сообщение", "сообщения", "сообщений"},
+		"vote":    {"голос", "голоса", "голосов"},
+		"view":    {"просмотр", "просмотра", "просмотров"},
+		"point":   {"очко", "очка", "очков"},
+	},
+	strings: map[string]string{
+		// Chat header
+		"Type:":         "Тип:",
+		"Type":          "Тип",
+		"Messages:":     "Сообщений:",
+		"Period:":       "Период:",
+		"Participants:": "Участники:",
+		"Source:":       "Источник:",
+		"Generated:":    "Создано:",
+		"Generator:":    "Конвертер:",
+
+		// Message details
+		"Self-destructing media (%s)":        "Самоуничтожающееся медиа (%s)",
+		"Author: %s":                         "Автор: %s",
+		"Forwarded from: %s":                 "Переслано от: %s",
+		"Saved from: %s":                     "Сохранено из: %s",
+		"Reply to message ID: %d in chat %s": "Ответ на сообщение %d в чате %s",
+		"Reply to %s":                        "Ответ на %s",
+		"Via bot: %s":                        "Через бота: %s",
+		"(edited at %s)":                     "(изменено %s)",
+		"Members: %s":                        "Участники: %s",
+		"Invited by: %s":                     "Приглашение от: %s",
+		"Title: %s":                          "Название: %s",
+		"by %s":                              "от %s",
+		"message":                            "сообщение",
+		"message ID:":                        "сообщение",
+
+		// Media
+		"Photo":                      "Фото",
+		"Photo:":                     "Фото:",
+		"File":                       "Файл",
+		"Voice message":              "Голосовое сообщение",
+		"Video message":              "Видеосообщение",
+		"Video":                      "Видео",
+		"Audio":                      "Аудио",
+		"Media":                      "Медиа",
+		"Sticker":                    "Стикер",
+		"sticker":                    "стикер",
+		"missing":                    "отсутствует",
+		"Spoiler":                    "Спойлер",
+		"not included in the export": "не включено в экспорт",
+
+		// Reactions, buttons, polls, contacts and locations
+		"Reactions:":     "Реакции:",
+		"Buttons:":       "Кнопки:",
+		"Poll":           "Опрос",
+		"Question:":      "Вопрос:",
+		"Options:":       "Варианты:",
+		"Poll is closed": "Опрос закрыт",
+		"Total voters:":  "Всего проголосовало:",
+		"Contact":        "Контакт",
+		"Name:":          "Имя:",
+		"Phone:":         "Телефон:",
+		"User ID:":       "ID пользователя:",
+		"Location":       "Местоположение",
+		"Coordinates:":   "Координаты:",
+		"Map Link:":      "Ссылка на карту:",
+
+		// Channel posts
+		"edited %s":      "изменено %s",
+		"In reply to %s": "В ответ на %s",
+		"post":           "пост",
+		"Post of %s":     "Пост от %s",
+		"Post %d":        "Пост %d",
+
+		// Forum topics
+		"General": "Общее",
+		"Topics":  "Темы",
+
+		// Media inventory
+		"Media Inventory": "Медиафайлы",
+		"**Files:** %d, present: %d, missing: %d, not included: %d": "**Файлов:** %d, есть: %d, отсутствуют: %d, не включены: %d",
+		"Message":      "Сообщение",
+		"Path":         "Путь",
+		"Size":         "Размер",
+		"Status":       "Статус",
+		"present":      "есть",
+		"not included": "не включено",
+
+		// Other output formats
+		"Telegram export":     "Экспорт Telegram",
+		"Telegram %s archive": "Архив Telegram (%s)",
+		"Chat":                "Чат",
+		"Message %d":          "Сообщение %d",
+		"Contents":            "Содержание",
+		"Forwarded from %s":   "Переслано от %s",
+		"(forwarded from %s)": "(переслано от %s)",
+		"(reply to #%d)":      "(ответ на #%d)",
+		"(edited)":            "(изменено)",
+
+		// Service messages
+		"Someone":                                     "Кто-то",
+		"%s created group «%s» with %s":               "%s создал(а) группу «%s» с участниками: %s",
+		"%s created group «%s»":                       "%s создал(а) группу «%s»",
+		"Channel «%s» created":                        "Канал «%s» создан",
+		"%s changed group title to «%s»":              "%s изменил(а) название группы на «%s»",
+		"%s changed group photo":                      "%s изменил(а) фото группы",
+		"%s removed group photo":                      "%s удалил(а) фото группы",
+		"%s joined the group":                         "%s присоединился(-ась) к группе",
+		"%s invited %s":                               "%s пригласил(а) %s",
+		"%s left the group":                           "%s покинул(а) группу",
+		"%s removed %s":                               "%s удалил(а) %s",
+		"%s joined the group via invite link from %s": "%s присоединился(-ась) к группе по ссылке-приглашению от %s",
+		"%s joined the group via invite link":         "%s присоединился(-ась) к группе по ссылке-приглашению",
+		"%s was accepted to the group":                "%s принят(а) в группу",
+		"Group was converted to a supergroup":         "Группа преобразована в супергруппу",
+		"%s converted group «%s» to a supergroup":     "%s преобразовал(а) группу «%s» в супергруппу",
+		"%s pinned %s":                                "%s закрепил(а) %s",
+		"History was cleared":                         "История очищена",
+		"%s scored %s in %s":                          "%s набрал(а) %s в %s",
+		"the game":                                    "игре",
+		"%s sent a payment of %s":                     "%s отправил(а) платёж на сумму %s",
+		"%s sent a payment of %s for %s":              "%s отправил(а) платёж на сумму %s по %s",
+		"invoice":                                     "счёту",
+		"🎙️ %s started a video chat (%s)":             "🎙️ %s начал(а) видеочат (%s)",
+		"🎙️ %s started a video chat":                  "🎙️ %s начал(а) видеочат",
+		"🎙️ %s scheduled a video chat for %s":         "🎙️ %s запланировал(а) видеочат на %s",
+		"🎙️ %s invited %s to the video chat":          "🎙️ %s пригласил(а) %s в видеочат",
+		"%s took a screenshot":                        "%s сделал(а) снимок экрана",
+		"%s allowed the bot to send messages":         "%s разрешил(а) боту отправлять сообщения",
+		"%s shared Telegram Passport data: %s":        "%s передал(а) данные Telegram Passport: %s",
+		"⏱️ %s disabled auto-delete timer":            "⏱️ %s отключил(а) автоудаление сообщений",
+		"⏱️ %s set messages to auto-delete after %s":  "⏱️ %s установил(а) автоудаление сообщений, таймер: %s",
+		"%s disabled the chat theme":                  "%s отключил(а) тему чата",
+		"%s changed the chat theme to %s":             "%s изменил(а) тему чата на %s",
+		"Data sent from the web app":                  "Данные отправлены из веб-приложения",
+		"🎁 %s gifted Telegram Premium for %s":         "🎁 %s подарил(а) Telegram Premium на %s",
+		"%s created topic «%s»":                       "%s создал(а) тему «%s»",
+		"%s renamed the topic to «%s»":                "%s переименовал(а) тему в «%s»",
+		"%s changed the topic icon":                   "%s изменил(а) значок темы",
+		"🚀 %s boosted the group %s":                   "🚀 %s забустил(а) группу %s",
+		"📞 Missed call from %s":                       "📞 Пропущенный звонок от %s",
+		"📞 Call from %s declined (busy)":              "📞 Звонок от %s отклонён (занято)",
+		"📞 Call from %s":                              "📞 Звонок от %s",
+		"%s, disconnected":                            "%s, разъединён",
+	},
+}
+
+// russianPluralForm selects between the forms for 1, 2-4 and 5 or more
+func russianPluralForm(n int) int {
+	if n < 0 {
+		n = -n
+	}
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return 0
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// tr translates an English format string into the output language and
+// formats it with the arguments
+func (p *JSONToMarkdown) tr(format string, args ...interface{}) string {
+	if p.locale != nil {
+		if translated, ok := p.locale.strings[format]; ok {
+			format = translated
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// plural formats a count with the unit name in its plural form
+func (p *JSONToMarkdown) plural(n int, unit string) string {
+	if p.locale != nil {
+		if forms, ok := p.locale.units[unit]; ok {
+			return fmt.Sprintf("%d %s", n, forms[p.locale.pluralForm(n)])
+		}
+	}
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// formatDate formats a time with month names of the output language
+func (p *JSONToMarkdown) formatDate(t time.Time, layout string) string {
+	formatted := t.Format(layout)
+	if p.locale == nil || !strings.Contains(layout, "January") {
+		return formatted
+	}
+
+	// Months take the genitive case after a day number
+	months := p.locale.months
+	if strings.Contains(layout, "2 January") {
+		months = p.locale.monthsOf
+	}
+	return strings.Replace(formatted, t.Month().String(), months[t.Month()-1], 1)
+}
+
+// displayDate formats an export date with the date format of the job
+func (p *JSONToMarkdown) displayDate(date string) string {
+	return p.formatDate(p.parseDate(date), p.dateFormat)
+}
+
+// bookLanguage returns the language code of documents that declare one,
+// "und" when English labels are mixed with messages in any language
+func (p *JSONToMarkdown) bookLanguage() string {
+	if p.language == "" {
+		return "und"
+	}
+	return p.language
+}
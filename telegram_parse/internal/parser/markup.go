@@ -0,0 +1,340 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"telegram_parse/internal/telegram"
+)
+
+// markupProperty is a metadata field of a chat or message heading
+type markupProperty struct {
+	Key   string
+	Value string
+}
+
+// markupSyntax renders the building blocks of a lightweight markup language
+// other than Markdown
+type markupSyntax interface {
+	// document returns the document title and settings
+	document(title string) string
+	// heading returns a heading with an anchor and metadata properties
+	heading(level int, title, anchor string, properties []markupProperty) string
+	// text escapes plain text
+	text(text string) string
+	// entity formats a text entity handled by formatText
+	entity(text, entityType string, entity map[string]interface{}) string
+	// messageLink links to the heading of a message
+	messageLink(anchor, label string) string
+	// note emphasizes a remark about a message
+	note(text string) string
+	// image embeds an image by its link relative to the output file
+	image(link string) string
+	// fileLink links to a file relative to the output file
+	fileLink(link, label string) string
+}
+
+// markupSyntax returns the syntax of the output format, nil for formats
+// without a markup renderer
+func (p *JSONToMarkdown) markupSyntax() markupSyntax {
+	switch p.outputFormat {
+	case FormatOrg:
+		return orgSyntax{}
+	case FormatAsciiDoc:
+		return asciidocSyntax{}
+	default:
+		return nil
+	}
+}
+
+// writeMarkup writes all chats into a single Org-mode or AsciiDoc document
+func (p *JSONToMarkdown) writeMarkup(exports []telegram.Export, outputPath string) error {
+	syntax := p.markupSyntax()
+
+	title := p.tr("Telegram export")
+	if len(exports) == 1 && exports[0].Name != "" {
+		title = exports[0].Name
+	}
+
+	var result strings.Builder
+	result.WriteString(syntax.document(title))
+
+	for i := range exports {
+		p.exportToMarkup(syntax, &exports[i], &result)
+
+		if p.mediaInventory {
+			p.conv.inventory = append(p.conv.inventory, p.collectMedia(&exports[i])...)
+		}
+	}
+
+	if err := os.WriteFile(outputPath, []byte(result.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return nil
+}
+
+// exportToMarkup writes a chat as a heading with a subheading per message
+func (p *JSONToMarkdown) exportToMarkup(syntax markupSyntax, export *telegram.Export, result *strings.Builder) {
+	p.scopeAnchors(export)
+	var properties []markupProperty
+	if p.includeMetadata {
+		properties = append(properties, markupProperty{"chat_type", export.Type})
+		if export.ID != 0 {
+			properties = append(properties, markupProperty{"chat_id", strconv.FormatInt(export.ID, 10)})
+		}
+		properties = append(properties, markupProperty{"messages", strconv.Itoa(len(export.Messages))})
+	}
+
+	name := export.Name
+	if name == "" {
+		name = p.tr("Chat")
+	}
+	result.WriteString(syntax.heading(1, syntax.text(name), "", properties))
+
+	for i := range export.Messages {
+		result.WriteString(p.messageToMarkup(syntax, &export.Messages[i]))
+	}
+}
+
+// messageToMarkup converts a single message to a heading with its content
+func (p *JSONToMarkdown) messageToMarkup(syntax markupSyntax, msg *telegram.Message) string {
+	if msg.Type == "service" && msg.Text == nil && msg.Action == "" {
+		return ""
+	}
+
+	var result strings.Builder
+
+	sender := msg.From
+	if msg.Type == "service" {
+		sender = msg.Actor
+	}
+
+	title := ""
+	if msg.Date != "" {
+		title = p.displayDate(msg.Date)
+	}
+	if sender != "" {
+		title = strings.TrimPrefix(title+" - "+sender, " - ")
+	}
+	if title == "" {
+		title = p.tr("Message %d", msg.ID)
+	}
+
+	anchor := ""
+	if msg.ID != 0 {
+		anchor = p.chatAnchor(msg.ID)
+	}
+	result.WriteString(syntax.heading(2, syntax.text(title), anchor, p.messageProperties(msg)))
+
+	if msg.Type == "service" {
+		sentence := p.describeAction(msg)
+		if sentence == "" {
+			sentence = msg.Action
+		}
+		result.WriteString(syntax.note(relinkMessages(sentence, func(id int64, label string) string {
+			return syntax.messageLink(p.chatAnchor(id), label)
+		})) + "\n\n")
+
+		if msg.Action == "edit_group_photo" && p.includeMedia {
+			result.WriteString(p.mediaToMarkup(syntax, msg))
+		}
+		return result.String()
+	}
+
+	if text := p.markupText(syntax, msg.Text); text != "" {
+		result.WriteString(text + "\n\n")
+	}
+
+	if p.includeMedia {
+		result.WriteString(p.mediaToMarkup(syntax, msg))
+	}
+
+	if msg.Poll != nil {
+		result.WriteString(syntax.note("📊 "+syntax.text(msg.Poll.Question)) + "\n\n")
+		for _, answer := range msg.Poll.Answers {
+			result.WriteString(fmt.Sprintf("- %s (%s)\n", syntax.text(answer.Text), p.plural(answer.Voters, "vote")))
+		}
+		result.WriteString("\n")
+	}
+
+	if contact := msg.ContactInformation; contact != nil {
+		name := strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+		result.WriteString(syntax.text(fmt.Sprintf("👤 %s %s", name, contact.PhoneNumber)) + "\n\n")
+	}
+
+	if location := msg.LocationInformation; location != nil {
+		result.WriteString(fmt.Sprintf("📍 %.6f, %.6f\n\n", location.Latitude, location.Longitude))
+	}
+
+	if len(msg.Reactions) > 0 {
+		var parts []string
+		for _, reaction := range msg.Reactions {
+			emoji := reaction.Emoji
+			if emoji == "" {
+				emoji = "🧩"
+			}
+			parts = append(parts, fmt.Sprintf("%s %d", emoji, reaction.Count))
+		}
+		result.WriteString(syntax.text(strings.Join(parts, " · ")) + "\n\n")
+	}
+
+	if msg.ReplyToMessageID != 0 && msg.ReplyToPeerID == "" {
+		label := fmt.Sprintf("%s %d", p.tr("message"), msg.ReplyToMessageID)
+		result.WriteString(syntax.note(p.tr("Reply to %s", syntax.messageLink(p.chatAnchor(msg.ReplyToMessageID), label))) + "\n\n")
+	}
+
+	return result.String()
+}
+
+// messageProperties lists the metadata of a message
+func (p *JSONToMarkdown) messageProperties(msg *telegram.Message) []markupProperty {
+	properties := []markupProperty{{"message_id", strconv.FormatInt(msg.ID, 10)}}
+
+	add := func(key, value string) {
+		if value != "" {
+			properties = append(properties, markupProperty{key, value})
+		}
+	}
+
+	add("type", msg.Type)
+	add("date", p.timestamp(msg.Date, msg.DateUnixtime))
+	add("from", msg.From)
+	add("from_id", msg.FromID)
+	add("author", msg.Author)
+	if msg.Edited != "" {
+		add("edited", p.timestamp(msg.Edited, msg.EditedUnixtime))
+	}
+	if msg.ReplyToMessageID != 0 {
+		add("reply_to", strconv.FormatInt(msg.ReplyToMessageID, 10))
+	}
+	add("reply_to_peer", msg.ReplyToPeerID)
+	add("forwarded_from", msg.ForwardedFrom)
+	add("saved_from", msg.SavedFrom)
+	add("via_bot", msg.ViaBot)
+	add("action", msg.Action)
+
+	return properties
+}
+
+// markupText formats message text with its entities
+func (p *JSONToMarkdown) markupText(syntax markupSyntax, text interface{}) string {
+	var result strings.Builder
+
+	switch t := text.(type) {
+	case string:
+		result.WriteString(syntax.text(t))
+	case []interface{}:
+		for _, item := range t {
+			switch v := item.(type) {
+			case string:
+				result.WriteString(syntax.text(v))
+			case map[string]interface{}:
+				textVal, _ := v["text"].(string)
+				typeVal, _ := v["type"].(string)
+				result.WriteString(syntax.entity(textVal, typeVal, v))
+			}
+		}
+	}
+
+	return strings.TrimSpace(result.String())
+}
+
+// mediaToMarkup describes the media of a message, embedding photos copied
+// next to the output
+func (p *JSONToMarkdown) mediaToMarkup(syntax markupSyntax, msg *telegram.Message) string {
+	var result strings.Builder
+
+	if msg.Photo != "" {
+		name := filepath.Base(msg.Photo)
+		switch status := p.mediaStatus(msg.Photo); {
+		case status == MediaNotIncluded:
+			result.WriteString(syntax.note("📷 "+p.tr("Photo")+" "+p.tr("not included in the export")) + "\n\n")
+		case status == MediaMissing:
+			result.WriteString(syntax.note("📷 "+p.tr("Photo")+" "+syntax.text(name)+" ("+p.tr("missing")+")") + "\n\n")
+		case !p.copiesMedia():
+			result.WriteString("📷 " + syntax.text(name) + "\n\n")
+		default:
+			if link, ok := p.relinkMedia(msg.Photo); ok {
+				result.WriteString(syntax.image(link) + "\n\n")
+			} else {
+				result.WriteString(syntax.note("📷 "+p.tr("Photo")+" "+syntax.text(name)+" ("+p.tr("missing")+")") + "\n\n")
+			}
+		}
+	}
+
+	if msg.File == "" && (msg.MediaType == "" || msg.MediaType == "photo") {
+		return result.String()
+	}
+
+	emoji, label := p.mediaLabel(msg.MediaType)
+	name := msg.FileName
+	if name == "" && msg.File != "" && !isMediaPlaceholder(msg.File) {
+		name = filepath.Base(msg.File)
+	}
+	if msg.MediaType == "sticker" && msg.StickerEmoji != "" {
+		name = msg.StickerEmoji
+	}
+
+	description := syntax.text(name)
+	if msg.File != "" && msg.MediaType != "sticker" {
+		switch status := p.mediaStatus(msg.File); {
+		case status == MediaNotIncluded:
+			description = strings.TrimSpace(description + " " + syntax.note("("+p.tr("not included in the export")+")"))
+		case status == MediaMissing:
+			description = strings.TrimSpace(description + " " + syntax.note("("+p.tr("missing")+")"))
+		case p.copiesMedia():
+			if link, ok := p.relinkMedia(msg.File); ok {
+				description = syntax.fileLink(link, name)
+			} else {
+				description = strings.TrimSpace(description + " " + syntax.note("("+p.tr("missing")+")"))
+			}
+		}
+	}
+
+	var details []string
+	if duration := mediaDuration(msg); duration > 0 {
+		details = append(details, formatDuration(duration))
+	}
+	if msg.FileSize > 0 {
+		details = append(details, formatSize(msg.FileSize))
+	}
+
+	result.WriteString(fmt.Sprintf("%s %s: %s", emoji, label, description))
+	if len(details) > 0 {
+		result.WriteString(fmt.Sprintf(" (%s)", strings.Join(details, ", ")))
+	}
+	result.WriteString("\n\n")
+
+	return result.String()
+}
+
+// relinkMessages turns Markdown links to messages written by messageLink
+// into links of another syntax
+func relinkMessages(text string, link func(id int64, label string) string) string {
+	return messageLinkPattern.ReplaceAllStringFunc(text, func(markdown string) string {
+		match := messageLinkPattern.FindStringSubmatch(markdown)
+		id, _ := strconv.ParseInt(match[2], 10, 64)
+		return link(id, match[1])
+	})
+}
+
+// wrapMarker surrounds text with emphasis markers, keeping surrounding
+// whitespace outside since markup languages do not allow it inside
+func wrapMarker(text, open, close string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+
+	start := strings.Index(text, trimmed)
+	return text[:start] + open + trimmed + close + text[start+len(trimmed):]
+}
+
+// propertyValue flattens a metadata value to a single line
+func propertyValue(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"telegram_parse/internal/telegram"
+)
+
+// Media modes controlling how referenced media files are handled
+const (
+	MediaModeReference = "reference" // Mention file names only
+	MediaModeCopy      = "copy"      // Copy files into the assets directory
+	MediaModeHardlink  = "hardlink"  // Hardlink files into the assets directory, copy if not possible
+)
+
+// copiesMedia reports whether media files are placed next to the output
+func (p *JSONToMarkdown) copiesMedia() bool {
+	return p.conv != nil && (p.mediaMode == MediaModeCopy || p.mediaMode == MediaModeHardlink)
+}
+
+// relinkMedia places a media file referenced by the export into the assets
+// directory and returns a link relative to the output file. Missing files
+// are recorded in the conversion report.
+func (p *JSONToMarkdown) relinkMedia(mediaPath string) (string, bool) {
+	clean := path.Clean(filepath.ToSlash(mediaPath))
+	if !fs.ValidPath(clean) || clean == "." {
+		p.recordMissing(mediaPath)
+		return "", false
+	}
+
+	rel := path.Join(p.conv.assetsDir, clean)
+	source := path.Join(p.conv.baseDir, clean)
+	target := filepath.Join(p.conv.outputDir, filepath.FromSlash(rel))
+
+	// ConvertFile sets diskDir to the directory of the JSON file itself
+	diskSource := ""
+	if p.conv.diskDir != "" {
+		diskSource = filepath.Join(p.conv.diskDir, filepath.FromSlash(clean))
+	}
+
+	if err := p.placeMedia(source, diskSource, target); err != nil {
+		p.recordMissing(mediaPath)
+		return "", false
+	}
+
+	return p.conv.linkPrefix + mediaLink(rel), true
+}
+
+// placeMedia copies or hardlinks a media file unless it is already in place
+func (p *JSONToMarkdown) placeMedia(source, diskSource, target string) error {
+	info, err := fs.Stat(p.conv.fsys, source)
+	if err != nil {
+		return err
+	}
+
+	if existing, err := os.Stat(target); err == nil && existing.Size() == info.Size() {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create assets directory: %w", err)
+	}
+
+	// Hardlinks are only possible for files on disk, fall back to copying
+	if p.mediaMode == MediaModeHardlink && diskSource != "" {
+		os.Remove(target)
+		if os.Link(diskSource, target) == nil {
+			return nil
+		}
+	}
+
+	return copyMedia(p.conv.fsys, source, target)
+}
+
+// copyMedia copies a file from the export file system to disk
+func copyMedia(fsys fs.FS, source, target string) error {
+	in, err := fsys.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to create media file: %w", err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(target)
+		return fmt.Errorf("failed to copy media file: %w", err)
+	}
+
+	return out.Close()
+}
+
+// recordMissing remembers a media path that could not be found
+func (p *JSONToMarkdown) recordMissing(mediaPath string) {
+	for _, missing := range p.conv.missing {
+		if missing == mediaPath {
+			return
+		}
+	}
+	p.conv.missing = append(p.conv.missing, mediaPath)
+}
+
+// processStickerImage renders a sticker as an image. Returns false if the
+// sticker has no displayable image.
+func (p *JSONToMarkdown) processStickerImage(msg *telegram.Message, result *strings.Builder) bool {
+	image := msg.Thumbnail
+	if image == "" && isImageFile(msg.File) {
+		image = msg.File
+	}
+	if image == "" {
+		return false
+	}
+
+	src, ok := p.imageSource(image)
+	if !ok {
+		return false
+	}
+
+	alt := msg.StickerEmoji
+	if alt == "" {
+		alt = "sticker"
+	}
+
+	result.WriteString(p.imageMarkdown(alt, src, msg.Width, msg.Height) + "\n\n")
+	return true
+}
+
+// imageSource returns a link to an image for embedding in the output.
+// Small images are inlined as data URIs when enabled, otherwise the image is
+// copied next to the output or linked relative to the export directory.
+func (p *JSONToMarkdown) imageSource(mediaPath string) (string, bool) {
+	clean := path.Clean(filepath.ToSlash(mediaPath))
+	if !fs.ValidPath(clean) || clean == "." {
+		p.recordMissing(mediaPath)
+		return "", false
+	}
+
+	source := path.Join(p.conv.baseDir, clean)
+	info, err := fs.Stat(p.conv.fsys, source)
+	if err != nil {
+		p.recordMissing(mediaPath)
+		return "", false
+	}
+
+	if p.inlineImageMax > 0 && info.Size() <= p.inlineImageMax {
+		if uri, err := p.dataURI(source); err == nil {
+			return uri, true
+		}
+	}
+
+	if p.copiesMedia() {
+		return p.relinkMedia(mediaPath)
+	}
+
+	// Link to the original file, only possible for exports on disk
+	if p.conv.diskDir == "" {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(p.conv.outputDir, filepath.Join(p.conv.diskDir, filepath.FromSlash(clean)))
+	if err != nil {
+		return "", false
+	}
+
+	return mediaLink(filepath.ToSlash(rel)), true
+}
+
+// dataURI reads an image from the export and encodes it as a data URI
+func (p *JSONToMarkdown) dataURI(source string) (string, error) {
+	data, err := fs.ReadFile(p.conv.fsys, source)
+	if err != nil {
+		return "", err
+	}
+
+	mimeType := mime.TypeByExtension(path.Ext(source))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// imageMarkdown returns an embedded image with its dimensions where the
+// output supports them: a kramdown attribute list on Jekyll, an HTML image
+// for flavors with inline HTML. Hugo drops raw HTML and chat flavors have
+// none, so they get plain image syntax.
+func (p *JSONToMarkdown) imageMarkdown(alt, src string, width, height int) string {
+	image := fmt.Sprintf("![%s](%s)", alt, src)
+	if width <= 0 || height <= 0 {
+		return image
+	}
+
+	switch {
+	case p.outputFormat == FormatJekyll:
+		return fmt.Sprintf("%s{: width=\"%d\" height=\"%d\"}", image, width, height)
+	case p.outputFormat == FormatHugo || p.flavor == FlavorTelegram || p.flavor == FlavorDiscord:
+		return image
+	default:
+		return fmt.Sprintf("<img src=\"%s\" alt=\"%s\" width=\"%d\" height=\"%d\">",
+			html.EscapeString(src), html.EscapeString(alt), width, height)
+	}
+}
+
+// isImageFile checks if file has a still image extension
+func isImageFile(name string) bool {
+	switch strings.ToLower(path.Ext(filepath.ToSlash(name))) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return true
+	}
+	return false
+}
+
+// mediaLink escapes a relative slash-separated path for use as a Markdown link
+func mediaLink(rel string) string {
+	segments := strings.Split(rel, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,227 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"telegram_parse/internal/telegram"
+)
+
+// peopleDir is the vault folder person notes are written to
+const peopleDir = "People"
+
+// noteNamePattern matches characters Obsidian does not allow in note names
+var noteNamePattern = regexp.MustCompile(`[\[\]#^|\\/:*?"<>]+`)
+
+// tagPattern matches characters not allowed in Obsidian tags
+var tagPattern = regexp.MustCompile(`[^\p{L}\p{N}_/-]+`)
+
+// person is a chat participant with a generated note
+type person struct {
+	Name   string
+	FromID string
+}
+
+// isObsidian reports whether the output is written for an Obsidian vault
+func (p *JSONToMarkdown) isObsidian() bool {
+	return p.outputFormat == FormatObsidian
+}
+
+// noteName returns a file name safe version of a person name
+func noteName(name string) string {
+	safe := strings.TrimSpace(noteNamePattern.ReplaceAllString(name, " "))
+	if safe == "" {
+		return "Unknown"
+	}
+	return safe
+}
+
+// wikilink returns an Obsidian link to the note of a person
+func wikilink(name string) string {
+	safe := noteName(name)
+	if safe == name {
+		return fmt.Sprintf("[[%s]]", name)
+	}
+	return fmt.Sprintf("[[%s|%s]]", safe, name)
+}
+
+// hashtagTerm converts a hashtag to a tag name without the leading "#", as
+// used by Obsidian tags and static site taxonomies
+func hashtagTerm(hashtag string) string {
+	return strings.Trim(tagPattern.ReplaceAllString(strings.TrimPrefix(hashtag, "#"), "_"), "_")
+}
+
+// obsidianEmbed returns an embed of a media file copied into the assets
+// directory
+func (p *JSONToMarkdown) obsidianEmbed(mediaPath string) string {
+	clean := path.Clean(filepath.ToSlash(mediaPath))
+	return fmt.Sprintf("![[%s]]", path.Join(p.conv.assetsDir, clean))
+}
+
+// recordPerson remembers a sender for person note generation
+func (p *JSONToMarkdown) recordPerson(msg *telegram.Message) {
+	if p.conv == nil || msg.From == "" {
+		return
+	}
+	if _, ok := p.conv.people[msg.From]; !ok {
+		p.conv.people[msg.From] = person{Name: msg.From, FromID: msg.FromID}
+	}
+}
+
+// frontMatter returns YAML front matter describing the converted chats and
+// records participants for person notes
+func (p *JSONToMarkdown) frontMatter(exports []telegram.Export) string {
+	var (
+		first, last  time.Time
+		participants []string
+		seen         = make(map[string]bool)
+		tags         = []string{"telegram"}
+		seenTags     = map[string]bool{"telegram": true}
+	)
+
+	for i := range exports {
+		for j := range exports[i].Messages {
+			msg := &exports[i].Messages[j]
+
+			if msg.Date != "" {
+				date := p.parseDate(msg.Date)
+				if first.IsZero() || date.Before(first) {
+					first = date
+				}
+				if date.After(last) {
+					last = date
+				}
+			}
+
+			if msg.From != "" && !seen[msg.From] {
+				seen[msg.From] = true
+				p.recordPerson(msg)
+				participants = append(participants, msg.From)
+			}
+
+			for _, hashtag := range messageHashtags(msg) {
+				if tag := hashtagTerm(hashtag); tag != "" && !seenTags[tag] {
+					seenTags[tag] = true
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	sort.Strings(participants)
+
+	var result strings.Builder
+	result.WriteString("---\n")
+
+	if len(exports) == 1 {
+		result.WriteString(fmt.Sprintf("chat: %s\n", yamlString(exports[0].Name)))
+		result.WriteString(fmt.Sprintf("type: %s\n", yamlString(exports[0].Type)))
+		if exports[0].ID != 0 {
+			result.WriteString(fmt.Sprintf("id: %d\n", exports[0].ID))
+		}
+	} else {
+		result.WriteString("chats:\n")
+		for i := range exports {
+			result.WriteString(fmt.Sprintf("  - %s\n", yamlString(exports[i].Name)))
+		}
+	}
+
+	if !first.IsZero() {
+		result.WriteString(fmt.Sprintf("date_start: %s\n", first.Format("2006-01-02T15:04:05")))
+		result.WriteString(fmt.Sprintf("date_end: %s\n", last.Format("2006-01-02T15:04:05")))
+	}
+
+	if len(participants) > 0 {
+		result.WriteString("participants:\n")
+		for _, name := range participants {
+			result.WriteString(fmt.Sprintf("  - %s\n", yamlString(wikilink(name))))
+		}
+	}
+
+	result.WriteString("tags:\n")
+	for _, tag := range tags {
+		result.WriteString(fmt.Sprintf("  - %s\n", yamlString(tag)))
+	}
+
+	result.WriteString("---\n\n")
+	return result.String()
+}
+
+// messageHashtags returns hashtags used in a message text
+func messageHashtags(msg *telegram.Message) []string {
+	items, ok := msg.Text.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var hashtags []string
+	for _, item := range items {
+		entity, ok := item.(map[string]interface{})
+		if !ok || entity["type"] != "hashtag" {
+			continue
+		}
+		if text, ok := entity["text"].(string); ok {
+			hashtags = append(hashtags, text)
+		}
+	}
+
+	return hashtags
+}
+
+// writePeopleNotes creates notes for chat participants in the vault. Existing
+// notes are left untouched so they can be edited by hand.
+func writePeopleNotes(vaultDir string, people map[string]person) error {
+	if len(people) == 0 {
+		return nil
+	}
+
+	dir := filepath.Join(vaultDir, peopleDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create people directory: %w", err)
+	}
+
+	for _, entry := range people {
+		notePath := filepath.Join(dir, noteName(entry.Name)+".md")
+
+		var note strings.Builder
+		note.WriteString("---\n")
+		note.WriteString("type: person\n")
+		if entry.FromID != "" {
+			note.WriteString(fmt.Sprintf("telegram_id: %s\n", yamlString(entry.FromID)))
+		}
+		if noteName(entry.Name) != entry.Name {
+			note.WriteString(fmt.Sprintf("aliases:\n  - %s\n", yamlString(entry.Name)))
+		}
+		note.WriteString("tags:\n  - telegram/person\n")
+		note.WriteString("---\n\n")
+		note.WriteString(fmt.Sprintf("# %s\n", entry.Name))
+
+		file, err := os.OpenFile(notePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create person note: %w", err)
+		}
+
+		_, err = file.WriteString(note.String())
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write person note: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// yamlString quotes a string for YAML. JSON strings are valid YAML scalars.
+func yamlString(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// orgSyntax renders Emacs Org-mode documents. Messages are headings with
+// their metadata in property drawers.
+type orgSyntax struct{}
+
+// document returns the title keyword of the document
+func (orgSyntax) document(title string) string {
+	return fmt.Sprintf("#+TITLE: %s\n#+STARTUP: overview\n\n", propertyValue(title))
+}
+
+// heading returns a headline followed by a property drawer
+func (orgSyntax) heading(level int, title, anchor string, properties []markupProperty) string {
+	var result strings.Builder
+	result.WriteString(strings.Repeat("*", level) + " " + propertyValue(title) + "\n")
+
+	if anchor != "" || len(properties) > 0 {
+		result.WriteString(":PROPERTIES:\n")
+		if anchor != "" {
+			result.WriteString(":CUSTOM_ID: " + anchor + "\n")
+		}
+		for _, property := range properties {
+			result.WriteString(fmt.Sprintf(":%s: %s\n", strings.ToUpper(property.Key), propertyValue(property.Value)))
+		}
+		result.WriteString(":END:\n")
+	}
+
+	result.WriteString("\n")
+	return result.String()
+}
+
+// orgMarkers open emphasis, verbatim, code and strike-through
+const orgMarkers = "*/_=~+"
+
+// orgMarkupPre lists the characters that may precede an opening marker
+const orgMarkupPre = " \t-({'\""
+
+// text keeps lines of text from being read as headlines, keywords, links or
+// emphasis. A zero width space is the usual Org escape for markup characters.
+func (orgSyntax) text(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		var result strings.Builder
+		if strings.HasPrefix(line, "#+") {
+			result.WriteString("\u200b")
+		}
+
+		// Lines start as if after a space, which also guards headlines
+		previous := ' '
+		for _, r := range line {
+			if (strings.ContainsRune(orgMarkers, r) && strings.ContainsRune(orgMarkupPre, previous)) ||
+				(r == '[' && previous == '[') {
+				result.WriteString("\u200b")
+			}
+			result.WriteRune(r)
+			previous = r
+		}
+		lines[i] = result.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// entity formats a text entity with Org-mode markup
+func (s orgSyntax) entity(text, entityType string, entity map[string]interface{}) string {
+	switch entityType {
+	case "bold":
+		return wrapMarker(text, "*", "*")
+	case "italic":
+		return wrapMarker(text, "/", "/")
+	case "code":
+		return wrapMarker(text, "~", "~")
+	case "pre":
+		language, _ := entity["language"].(string)
+		return fmt.Sprintf("\n#+BEGIN_SRC %s\n%s\n#+END_SRC\n", language, strings.Trim(text, "\n"))
+	case "text_link":
+		if href, ok := entity["href"].(string); ok {
+			return fmt.Sprintf("[[%s][%s]]", href, orgLinkLabel(text))
+		}
+		return s.text(text)
+	case "link":
+		return fmt.Sprintf("[[%s]]", text)
+	case "email":
+		return fmt.Sprintf("[[mailto:%s][%s]]", text, text)
+	case "strikethrough":
+		return wrapMarker(text, "+", "+")
+	case "underline":
+		return wrapMarker(text, "_", "_")
+	case "spoiler":
+		// Org has no spoilers, HTML export gets a styled span
+		return wrapMarker(text, "@@html:<span class=\"spoiler\">@@", "@@html:</span>@@")
+	case "blockquote":
+		return fmt.Sprintf("\n#+BEGIN_QUOTE\n%s\n#+END_QUOTE\n", s.text(strings.Trim(text, "\n")))
+	default:
+		return s.text(text)
+	}
+}
+
+// messageLink links to the custom ID of a message headline
+func (orgSyntax) messageLink(anchor, label string) string {
+	return fmt.Sprintf("[[#%s][%s]]", anchor, orgLinkLabel(label))
+}
+
+// note returns italic text
+func (orgSyntax) note(text string) string {
+	return wrapMarker(text, "/", "/")
+}
+
+// image returns a file link without description, which Org displays inline
+func (orgSyntax) image(link string) string {
+	return fmt.Sprintf("[[file:%s]]", orgPath(link))
+}
+
+// fileLink returns a file link with a description
+func (orgSyntax) fileLink(link, label string) string {
+	return fmt.Sprintf("[[file:%s][%s]]", orgPath(link), orgLinkLabel(label))
+}
+
+// orgPath turns an escaped media link back into a file path, since Org file
+// links are not URL encoded
+func orgPath(link string) string {
+	if unescaped, err := url.PathUnescape(link); err == nil {
+		return unescaped
+	}
+	return link
+}
+
+// orgLinkLabel removes brackets that would end a link description
+func orgLinkLabel(label string) string {
+	return strings.NewReplacer("[", "(", "]", ")").Replace(label)
+}
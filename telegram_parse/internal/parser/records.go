@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"telegram_parse/internal/telegram"
+)
+
+// MessageRecord is a normalized, typed form of a Telegram message
+type MessageRecord struct {
+	ID         int64           `json:"id"`
+	ChatID     int64           `json:"chat_id"`
+	ChatName   string          `json:"chat_name"`
+	Type       string          `json:"type"` // "message" or "service"
+	Timestamp  string          `json:"timestamp"`
+	Edited     string          `json:"edited,omitempty"`
+	SenderID   string          `json:"sender_id,omitempty"`
+	SenderName string          `json:"sender_name,omitempty"`
+	Text       string          `json:"text"`
+	Markdown   string          `json:"markdown"`
+	Entities   []EntityRecord  `json:"entities"`
+	Media      *MediaRecord    `json:"media,omitempty"`
+	ReplyToID  int64           `json:"reply_to_id,omitempty"`
+	Forward    *ForwardRecord  `json:"forward,omitempty"`
+	Action     string          `json:"action,omitempty"`
+	Reactions  []ReactionCount `json:"reactions,omitempty"`
+}
+
+// EntityRecord is a formatted fragment of a message text
+type EntityRecord struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Href   string `json:"href,omitempty"`
+	UserID string `json:"user_id,omitempty"`
+}
+
+// MediaRecord describes the media attached to a message
+type MediaRecord struct {
+	Type     string `json:"type"`
+	Path     string `json:"path,omitempty"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Duration int    `json:"duration,omitempty"`
+	Status   string `json:"status"` // "present", "missing", "not_included"
+}
+
+// ForwardRecord describes where a message was forwarded or saved from
+type ForwardRecord struct {
+	From      string `json:"from,omitempty"`
+	FromID    string `json:"from_id,omitempty"`
+	SavedFrom string `json:"saved_from,omitempty"`
+}
+
+// ReactionCount is the number of reactions of one kind
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// messageRecord converts a message to its normalized form
+func (p *JSONToMarkdown) messageRecord(export *telegram.Export, msg *telegram.Message) MessageRecord {
+	record := MessageRecord{
+		ID:         msg.ID,
+		ChatID:     export.ID,
+		ChatName:   export.Name,
+		Type:       msg.Type,
+		Timestamp:  p.timestamp(msg.Date, msg.DateUnixtime),
+		SenderID:   msg.FromID,
+		SenderName: msg.From,
+		Text:       plainText(msg.Text),
+		Markdown:   p.extractTextContent(msg.Text, msg.TextEntities),
+		Entities:   messageEntities(msg),
+		Media:      p.mediaRecord(msg),
+		ReplyToID:  msg.ReplyToMessageID,
+		Action:     msg.Action,
+	}
+
+	if msg.Edited != "" {
+		record.Edited = p.timestamp(msg.Edited, msg.EditedUnixtime)
+	}
+
+	// Service messages are described by their actor
+	if msg.Type == "service" {
+		record.SenderName = msg.Actor
+		record.Markdown = p.describeAction(msg)
+	}
+
+	if msg.ForwardedFrom != "" || msg.ForwardedFromID != "" || msg.SavedFrom != "" {
+		record.Forward = &ForwardRecord{
+			From:      msg.ForwardedFrom,
+			FromID:    msg.ForwardedFromID,
+			SavedFrom: msg.SavedFrom,
+		}
+	}
+
+	for _, reaction := range msg.Reactions {
+		emoji := reaction.Emoji
+		if emoji == "" {
+			emoji = reaction.Type
+		}
+		record.Reactions = append(record.Reactions, ReactionCount{Emoji: emoji, Count: reaction.Count})
+	}
+
+	return record
+}
+
+// timestamp converts an export date to RFC 3339. The Unix time is preferred
+// since dates are written in the local time zone of the exporting machine.
+// Without it the date stays a local date-time with no offset.
+func (p *JSONToMarkdown) timestamp(date, unixtime string) string {
+	if seconds, err := strconv.ParseInt(unixtime, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+	}
+	if date == "" {
+		return ""
+	}
+	return p.parseDate(date).Format(localDateTimeFormat)
+}
+
+// messageEntities lists the text fragments of a message
+func messageEntities(msg *telegram.Message) []EntityRecord {
+	entities := []EntityRecord{}
+
+	if len(msg.TextEntities) > 0 {
+		for _, entity := range msg.TextEntities {
+			entities = append(entities, EntityRecord(entity))
+		}
+		return entities
+	}
+
+	switch text := msg.Text.(type) {
+	case string:
+		if text != "" {
+			entities = append(entities, EntityRecord{Type: "plain", Text: text})
+		}
+	case []interface{}:
+		for _, item := range text {
+			switch v := item.(type) {
+			case string:
+				entities = append(entities, EntityRecord{Type: "plain", Text: v})
+			case map[string]interface{}:
+				entity := EntityRecord{}
+				entity.Type, _ = v["type"].(string)
+				entity.Text, _ = v["text"].(string)
+				entity.Href, _ = v["href"].(string)
+				if userID, ok := v["user_id"]; ok {
+					entity.UserID = fmt.Sprint(userID)
+				}
+				entities = append(entities, entity)
+			}
+		}
+	}
+
+	return entities
+}
+
+// mediaRecord describes the photo or file of a message, nil without media
+func (p *JSONToMarkdown) mediaRecord(msg *telegram.Message) *MediaRecord {
+	var media *MediaRecord
+
+	switch {
+	case msg.Photo != "":
+		media = &MediaRecord{Type: "photo", Path: msg.Photo}
+	case msg.File != "" || msg.MediaType != "":
+		mediaType := msg.MediaType
+		if mediaType == "" {
+			mediaType = "file"
+		}
+		media = &MediaRecord{
+			Type:     mediaType,
+			Path:     msg.File,
+			FileName: msg.FileName,
+			MimeType: msg.MimeType,
+			Size:     msg.FileSize,
+		}
+	default:
+		return nil
+	}
+
+	media.Width = msg.Width
+	media.Height = msg.Height
+	media.Duration = mediaDuration(msg)
+
+	if media.Path == "" {
+		media.Status = MediaNotIncluded
+		return media
+	}
+
+	media.Status = p.mediaStatus(media.Path)
+	if media.Status == MediaNotIncluded {
+		media.Path = ""
+	}
+
+	return media
+}
+
+// writeJSONL writes one normalized record per message
+func (p *JSONToMarkdown) writeJSONL(exports []telegram.Export, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+
+	for i := range exports {
+		for j := range exports[i].Messages {
+			record := p.messageRecord(&exports[i], &exports[i].Messages[j])
+			if err := encoder.Encode(record); err != nil {
+				os.Remove(outputPath)
+				return fmt.Errorf("failed to write record: %w", err)
+			}
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	// Media inventory is written as CSV only
+	if p.mediaInventory {
+		for i := range exports {
+			p.conv.inventory = append(p.conv.inventory, p.collectMedia(&exports[i])...)
+		}
+	}
+
+	return nil
+}
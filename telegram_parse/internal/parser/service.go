@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"telegram_parse/internal/telegram"
+)
+
+// zeroDecimalCurrencies lists currencies without fractional units
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true, "KRW": true, "VND": true, "CLP": true, "ISK": true, "UGX": true, "XTR": true,
+}
+
+// describeAction returns a human readable sentence for a service message
+// action, or an empty string for unknown actions
+func (p *JSONToMarkdown) describeAction(msg *telegram.Message) string {
+	actor := msg.Actor
+	if actor == "" {
+		actor = p.tr("Someone")
+	}
+	members := strings.Join(msg.Members, ", ")
+
+	switch msg.Action {
+	case "create_group":
+		if members != "" {
+			return p.tr("%s created group «%s» with %s", actor, msg.Title, members)
+		}
+		return p.tr("%s created group «%s»", actor, msg.Title)
+	case "create_channel":
+		return p.tr("Channel «%s» created", msg.Title)
+	case "edit_group_title":
+		return p.tr("%s changed group title to «%s»", actor, msg.Title)
+	case "edit_group_photo":
+		return p.tr("%s changed group photo", actor)
+	case "delete_group_photo":
+		return p.tr("%s removed group photo", actor)
+	case "invite_members":
+		if len(msg.Members) == 1 && msg.Members[0] == msg.Actor {
+			return p.tr("%s joined the group", actor)
+		}
+		return p.tr("%s invited %s", actor, members)
+	case "remove_members":
+		if len(msg.Members) == 1 && msg.Members[0] == msg.Actor {
+			return p.tr("%s left the group", actor)
+		}
+		return p.tr("%s removed %s", actor, members)
+	case "join_group_by_link":
+		if msg.Inviter != "" {
+			return p.tr("%s joined the group via invite link from %s", actor, msg.Inviter)
+		}
+		return p.tr("%s joined the group via invite link", actor)
+	case "join_group_by_request":
+		return p.tr("%s was accepted to the group", actor)
+	case "migrate_to_supergroup":
+		return p.tr("Group was converted to a supergroup")
+	case "migrate_from_group":
+		return p.tr("%s converted group «%s» to a supergroup", actor, msg.Title)
+	case "pin_message":
+		return p.tr("%s pinned %s", actor, p.messageLink(msg.MessageID, p.tr("message")))
+	case "clear_history":
+		return p.tr("History was cleared")
+	case "score_in_game":
+		return p.tr("%s scored %s in %s", actor, p.plural(msg.Score, "point"), p.messageLink(msg.GameMessageID, p.tr("the game")))
+	case "send_payment":
+		amount := formatAmount(msg.Amount, msg.Currency)
+		if msg.InvoiceMessageID != 0 {
+			return p.tr("%s sent a payment of %s for %s", actor, amount, p.messageLink(msg.InvoiceMessageID, p.tr("invoice")))
+		}
+		return p.tr("%s sent a payment of %s", actor, amount)
+	case "phone_call":
+		return p.describeCall(actor, mediaDuration(msg), msg.DiscardReason)
+	case "group_call":
+		if duration := mediaDuration(msg); duration > 0 {
+			return p.tr("🎙️ %s started a video chat (%s)", actor, formatDuration(duration))
+		}
+		return p.tr("🎙️ %s started a video chat", actor)
+	case "group_call_scheduled":
+		return p.tr("🎙️ %s scheduled a video chat for %s", actor, p.displayDate(msg.ScheduleDate))
+	case "invite_to_group_call":
+		return p.tr("🎙️ %s invited %s to the video chat", actor, members)
+	case "take_screenshot":
+		return p.tr("%s took a screenshot", actor)
+	case "allow_sending_messages":
+		return p.tr("%s allowed the bot to send messages", actor)
+	case "send_passport_values":
+		return p.tr("%s shared Telegram Passport data: %s", actor, strings.Join(msg.Values, ", "))
+	case "set_messages_ttl":
+		if msg.Period == 0 {
+			return p.tr("⏱️ %s disabled auto-delete timer", actor)
+		}
+		return p.tr("⏱️ %s set messages to auto-delete after %s", actor, p.formatPeriod(msg.Period))
+	case "set_chat_theme":
+		if msg.Emoticon == "" {
+			return p.tr("%s disabled the chat theme", actor)
+		}
+		return p.tr("%s changed the chat theme to %s", actor, msg.Emoticon)
+	case "send_webview_data":
+		return p.tr("Data sent from the web app")
+	case "gift_premium":
+		return p.tr("🎁 %s gifted Telegram Premium for %s", actor, p.plural(msg.Months, "month"))
+	case "topic_created":
+		return p.tr("%s created topic «%s»", actor, msg.Title)
+	case "topic_edit":
+		if msg.NewTitle != "" {
+			return p.tr("%s renamed the topic to «%s»", actor, msg.NewTitle)
+		}
+		return p.tr("%s changed the topic icon", actor)
+	case "boost_apply":
+		return p.tr("🚀 %s boosted the group %s", actor, p.plural(msg.Boosts, "time"))
+	default:
+		return ""
+	}
+}
+
+// describeCall returns a sentence for a phone call
+func (p *JSONToMarkdown) describeCall(actor string, duration int, discardReason string) string {
+	switch discardReason {
+	case "missed":
+		return p.tr("📞 Missed call from %s", actor)
+	case "busy":
+		return p.tr("📞 Call from %s declined (busy)", actor)
+	}
+
+	sentence := p.tr("📞 Call from %s", actor)
+	if duration > 0 {
+		sentence += fmt.Sprintf(" (%s)", formatDuration(duration))
+	}
+	if discardReason == "disconnect" {
+		sentence = p.tr("%s, disconnected", sentence)
+	}
+	return sentence
+}
+
+// messageLink links a message ID to its anchor in the output
+func (p *JSONToMarkdown) messageLink(id int64, text string) string {
+	if id == 0 {
+		return text
+	}
+	if link, ok := p.siteMessageLink(id); ok {
+		return fmt.Sprintf("[%s %d](%s)", text, id, link)
+	}
+	return fmt.Sprintf("[%s %d](#%s)", text, id, p.chatAnchor(id))
+}
+
+// flavorMessageLinks replaces links to messages with their labels for the
+// Telegram flavor, which writes no anchors to link to
+func (p *JSONToMarkdown) flavorMessageLinks(text string) string {
+	if p.flavor != FlavorTelegram {
+		return text
+	}
+	return relinkMessages(text, func(_ int64, label string) string { return label })
+}
+
+// messageLinkPattern matches links to messages written by messageLink
+var messageLinkPattern = regexp.MustCompile(`\[([^\]]+)\]\(#(?:chat--?\d+-)?message-(\d+)\)`)
+
+// messageAnchor returns the anchor name of a message
+func messageAnchor(id int64) string {
+	return fmt.Sprintf("message-%d", id)
+}
+
+// chatAnchor returns the anchor name of a message of the current chat.
+// Message IDs repeat across chats, so files with several chats include the
+// chat ID.
+func (p *JSONToMarkdown) chatAnchor(id int64) string {
+	if p.conv == nil || p.conv.anchorChat == 0 {
+		return messageAnchor(id)
+	}
+	return scopedAnchor(p.conv.anchorChat, id)
+}
+
+// scopedAnchor returns the anchor name of a message including its chat ID
+func scopedAnchor(chat, id int64) string {
+	return fmt.Sprintf("chat-%d-%s", chat, messageAnchor(id))
+}
+
+// scopeAnchors sets the chat of message anchors written next, for files
+// with several chats
+func (p *JSONToMarkdown) scopeAnchors(export *telegram.Export) {
+	if p.conv != nil && p.conv.multiChat {
+		p.conv.anchorChat = export.ID
+	}
+}
+
+// formatAmount formats an amount given in minimal currency units
+func formatAmount(amount int64, currency string) string {
+	if zeroDecimalCurrencies[currency] {
+		return fmt.Sprintf("%d %s", amount, currency)
+	}
+	return fmt.Sprintf("%.2f %s", float64(amount)/100, currency)
+}
+
+// formatPeriod formats an auto-delete period in seconds
+func (p *JSONToMarkdown) formatPeriod(seconds int) string {
+	switch {
+	case seconds%(7*86400) == 0:
+		return p.plural(seconds/(7*86400), "week")
+	case seconds%86400 == 0:
+		return p.plural(seconds/86400, "day")
+	case seconds%3600 == 0:
+		return p.plural(seconds/3600, "hour")
+	case seconds%60 == 0:
+		return p.plural(seconds/60, "minute")
+	default:
+		return p.plural(seconds, "second")
+	}
+}
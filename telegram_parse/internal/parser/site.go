@@ -0,0 +1,351 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"telegram_parse/internal/telegram"
+)
+
+// Static site page grouping
+const (
+	SitePagePost  = "post"  // One page per message
+	SitePageDay   = "day"   // One page per day
+	SitePageMonth = "month" // One page per month
+)
+
+// siteDir is the directory sites are written to when no output directory
+// is selected
+const siteDir = "site"
+
+// slugPattern matches characters not allowed in URL slugs
+var slugPattern = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// sitePage is a page of a static site with the messages it contains
+type sitePage struct {
+	Key      string // Unique part of the file name
+	ID       int64  // Message ID of single post pages
+	Title    string
+	Date     time.Time
+	Messages []*telegram.Message
+}
+
+// isSite reports whether the output is a static site content tree
+func (p *JSONToMarkdown) isSite() bool {
+	return p.outputFormat == FormatHugo || p.outputFormat == FormatJekyll
+}
+
+// slugify converts a title to a lowercase URL slug
+func slugify(title string) string {
+	return strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(title), "-"), "-")
+}
+
+// chatSlug returns the URL slug of a chat, made unique by the chat ID
+func chatSlug(export *telegram.Export) string {
+	slug := slugify(export.Name)
+	switch {
+	case slug == "":
+		return fmt.Sprintf("chat-%d", export.ID)
+	case export.ID != 0:
+		return fmt.Sprintf("%s-%d", slug, export.ID)
+	default:
+		return slug
+	}
+}
+
+// convertSite writes chats as static site content. Sites are written to the
+// selected output directory, or to a "site" directory next to the output.
+// It returns the index page of the first chat, which marks the conversion as
+// done for incremental runs.
+func (p *JSONToMarkdown) convertSite(exports []telegram.Export) (string, error) {
+	root := p.rootDir
+	if root == "" {
+		root = filepath.Join(p.conv.outputDir, siteDir)
+	}
+
+	var firstIndex string
+	for i := range exports {
+		indexPath, err := p.exportToSite(root, &exports[i])
+		if err != nil {
+			return "", err
+		}
+		if firstIndex == "" {
+			firstIndex = indexPath
+		}
+	}
+
+	if p.outputFormat == FormatHugo {
+		if err := writeHugoTaxonomies(root); err != nil {
+			return "", err
+		}
+	}
+	return firstIndex, nil
+}
+
+// exportToSite writes the pages and the index of a single chat and returns
+// the path of the index
+func (p *JSONToMarkdown) exportToSite(root string, export *telegram.Export) (string, error) {
+	slug := chatSlug(export)
+
+	// Media goes to the static folder and is linked by absolute site paths
+	p.conv.linkPrefix = "/"
+	if p.outputFormat == FormatHugo {
+		p.conv.outputDir = filepath.Join(root, "static")
+		p.conv.assetsDir = slug
+	} else {
+		p.conv.outputDir = root
+		p.conv.assetsDir = path.Join("assets", slug)
+	}
+
+	// Replies link to messages on other pages through the site generator
+	pages := p.sitePagesOf(export)
+	p.conv.siteSlug = slug
+	p.conv.pageOf = make(map[int64]*sitePage)
+	for _, page := range pages {
+		for _, msg := range page.Messages {
+			p.conv.pageOf[msg.ID] = page
+		}
+	}
+	defer func() {
+		p.conv.pageOf = nil
+		p.conv.sitePage = nil
+	}()
+
+	var index strings.Builder
+	for _, page := range pages {
+		p.conv.sitePage = page
+
+		var content strings.Builder
+		content.WriteString(p.pageFrontMatter(export, slug, page))
+
+		if p.sitePages == SitePagePost {
+			p.writePostBody(page.Messages[0], &content)
+		} else {
+			p.writeMessages(page.Messages, &content)
+		}
+
+		pagePath := filepath.Join(root, "_posts", p.jekyllPostName(slug, page)+".md")
+		if p.outputFormat == FormatHugo {
+			pagePath = filepath.Join(root, "content", slug, page.Key+".md")
+		}
+
+		if err := writeSiteFile(pagePath, content.String()); err != nil {
+			return "", err
+		}
+
+		index.WriteString(fmt.Sprintf("- [%s](%s) (%s)\n", page.Title, p.sitePageLink(slug, page, ""), page.Date.Format("2006-01-02")))
+	}
+
+	// Media inventory of the chat, written as CSV only
+	if p.mediaInventory {
+		p.conv.inventory = append(p.conv.inventory, p.collectMedia(export)...)
+	}
+
+	return p.writeSiteIndex(root, slug, export, index.String())
+}
+
+// jekyllPostName returns the file name of a Jekyll post without extension
+func (p *JSONToMarkdown) jekyllPostName(slug string, page *sitePage) string {
+	name := page.Date.Format("2006-01-02") + "-" + slug
+	if p.sitePages == SitePagePost {
+		name += fmt.Sprintf("-%d", page.ID)
+	}
+	return name
+}
+
+// sitePageLink returns a link to a page of the chat, resolved by the site
+// generator, optionally to an anchor on the page
+func (p *JSONToMarkdown) sitePageLink(slug string, page *sitePage, anchor string) string {
+	if p.outputFormat == FormatHugo {
+		if anchor != "" {
+			return fmt.Sprintf(`{{< relref "%s#%s" >}}`, page.Key, anchor)
+		}
+		return fmt.Sprintf(`{{< relref "%s" >}}`, page.Key)
+	}
+
+	link := fmt.Sprintf("{%% post_url %s %%}", p.jekyllPostName(slug, page))
+	if anchor != "" {
+		link += "#" + anchor
+	}
+	return link
+}
+
+// siteMessageLink returns a link to a message on another page of the site
+// being written. Single post pages have no message anchors.
+func (p *JSONToMarkdown) siteMessageLink(id int64) (string, bool) {
+	if p.conv == nil || p.conv.pageOf == nil {
+		return "", false
+	}
+
+	page, ok := p.conv.pageOf[id]
+	if !ok || page == p.conv.sitePage {
+		return "", false
+	}
+
+	anchor := ""
+	if p.sitePages != SitePagePost {
+		anchor = p.chatAnchor(id)
+	}
+	return p.sitePageLink(p.conv.siteSlug, page, anchor), true
+}
+
+// sitePagesOf groups the messages of a chat into pages. Service messages do
+// not get pages of their own.
+func (p *JSONToMarkdown) sitePagesOf(export *telegram.Export) []*sitePage {
+	var pages []*sitePage
+	byKey := make(map[string]*sitePage)
+
+	for i := range export.Messages {
+		msg := &export.Messages[i]
+		date := p.parseDate(msg.Date)
+
+		var key, title string
+		switch p.sitePages {
+		case SitePagePost:
+			if msg.Type == "service" {
+				continue
+			}
+			key = fmt.Sprintf("%s-%d", date.Format("2006-01-02"), msg.ID)
+			title = p.postTitle(msg)
+		case SitePageDay:
+			key = date.Format("2006-01-02")
+			title = fmt.Sprintf("%s — %s", export.Name, key)
+		default:
+			key = date.Format("2006-01")
+			title = fmt.Sprintf("%s — %s", export.Name, p.formatDate(date, "January 2006"))
+		}
+
+		page, ok := byKey[key]
+		if !ok {
+			page = &sitePage{Key: key, ID: msg.ID, Title: title, Date: date}
+			byKey[key] = page
+			pages = append(pages, page)
+		}
+		page.Messages = append(page.Messages, msg)
+	}
+
+	return pages
+}
+
+// pageFrontMatter returns YAML front matter of a page with taxonomy terms
+// from hashtags and senders
+func (p *JSONToMarkdown) pageFrontMatter(export *telegram.Export, slug string, page *sitePage) string {
+	var tags, senders []string
+	seen := make(map[string]bool)
+
+	for _, msg := range page.Messages {
+		for _, hashtag := range messageHashtags(msg) {
+			if tag := hashtagTerm(hashtag); tag != "" && !seen["#"+tag] {
+				seen["#"+tag] = true
+				tags = append(tags, tag)
+			}
+		}
+
+		sender := msg.From
+		if msg.Author != "" {
+			sender = msg.Author
+		}
+		if sender != "" && !seen["@"+sender] {
+			seen["@"+sender] = true
+			senders = append(senders, sender)
+		}
+	}
+
+	var result strings.Builder
+	result.WriteString("---\n")
+
+	if p.outputFormat == FormatJekyll {
+		result.WriteString("layout: post\n")
+	}
+	result.WriteString(fmt.Sprintf("title: %s\n", yamlString(page.Title)))
+
+	if p.outputFormat == FormatJekyll {
+		result.WriteString(fmt.Sprintf("date: %s\n", page.Date.Format("2006-01-02 15:04:05")))
+		result.WriteString(fmt.Sprintf("categories:\n  - %s\n", yamlString(slug)))
+	} else {
+		result.WriteString(fmt.Sprintf("date: %s\n", page.Date.Format("2006-01-02T15:04:05")))
+		result.WriteString(fmt.Sprintf("chats:\n  - %s\n", yamlString(export.Name)))
+	}
+
+	writeYAMLList(&result, "tags", tags)
+	writeYAMLList(&result, "senders", senders)
+
+	result.WriteString("---\n\n")
+	return result.String()
+}
+
+// writeSiteIndex writes the page of a chat listing all of its pages and
+// returns its path
+func (p *JSONToMarkdown) writeSiteIndex(root, slug string, export *telegram.Export, list string) (string, error) {
+	var result strings.Builder
+	result.WriteString("---\n")
+
+	var indexPath string
+	if p.outputFormat == FormatHugo {
+		indexPath = filepath.Join(root, "content", slug, "_index.md")
+	} else {
+		indexPath = filepath.Join(root, slug, "index.md")
+		result.WriteString("layout: page\n")
+	}
+
+	result.WriteString(fmt.Sprintf("title: %s\n", yamlString(export.Name)))
+	result.WriteString(fmt.Sprintf("description: %s\n", yamlString(p.tr("Telegram %s archive", export.Type))))
+	result.WriteString("---\n\n")
+	result.WriteString(list)
+
+	return indexPath, writeSiteFile(indexPath, result.String())
+}
+
+// hugoConfigFiles are site configuration files that may declare taxonomies
+var hugoConfigFiles = []string{
+	"hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json",
+	"config.toml", "config.yaml", "config.yml", "config.json",
+}
+
+// writeHugoTaxonomies declares the taxonomies used by the generated pages
+// unless the site already has them configured
+func writeHugoTaxonomies(root string) error {
+	configPath := filepath.Join(root, "config", "_default", "taxonomies.toml")
+	if _, err := os.Stat(configPath); err == nil {
+		return nil
+	}
+	for _, name := range hugoConfigFiles {
+		if config, err := os.ReadFile(filepath.Join(root, name)); err == nil && strings.Contains(string(config), "taxonomies") {
+			return nil
+		}
+	}
+
+	// Hugo's default categories are kept next to the generated taxonomies
+	config := "category = \"categories\"\ntag = \"tags\"\nsender = \"senders\"\nchat = \"chats\"\n"
+	return writeSiteFile(configPath, config)
+}
+
+// writeSiteFile writes a site file, creating its directory
+func writeSiteFile(filePath, content string) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create site directory: %w", err)
+	}
+
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write site file: %w", err)
+	}
+
+	return nil
+}
+
+// writeYAMLList writes a YAML list, omitted when empty
+func writeYAMLList(result *strings.Builder, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	result.WriteString(key + ":\n")
+	for _, value := range values {
+		result.WriteString(fmt.Sprintf("  - %s\n", yamlString(value)))
+	}
+}
@@ -0,0 +1,262 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"telegram_parse/internal/telegram"
+)
+
+// templateExtensions are stripped from template file names to get the
+// extension of the output
+var templateExtensions = []string{".tmpl", ".tpl", ".gotmpl"}
+
+// TemplateData is the view model passed to user templates
+type TemplateData struct {
+	Source    string          // Name of the converted JSON file
+	Generated time.Time       // Time of the conversion
+	Chat      *TemplateChat   // First chat, the only one for single chat exports
+	Chats     []*TemplateChat // All chats of the file
+}
+
+// TemplateChat is a chat in the template view model
+type TemplateChat struct {
+	ID           int64
+	Name         string
+	Type         string
+	Messages     []*TemplateMessage
+	Participants []string // Senders in order of their first message
+}
+
+// TemplateMessage is a message in the template view model
+type TemplateMessage struct {
+	ID            int64
+	Anchor        string // Anchor name, "message-<ID>", prefixed with "chat-<chat ID>-" in full account exports
+	Type          string // "message" or "service"
+	Time          time.Time
+	Edited        time.Time // Zero if the message was not edited
+	Sender        string
+	SenderID      string
+	Text          string            // Text without formatting
+	Markdown      string            // Text rendered in the selected Markdown flavor
+	HTML          htmltemplate.HTML // Text rendered as HTML
+	Action        string            // Service action, such as "pin_message"
+	Service       string            // Sentence describing a service message
+	Media         *MediaRecord      // Attached photo or file, nil without media
+	MediaLink     string            // Link to media copied next to the output
+	ReplyToID     int64
+	ReplyTo       *TemplateMessage // Replied message, nil if it is not in the chat
+	ForwardedFrom string
+	Reactions     []ReactionCount
+}
+
+// templateFuncs are helper functions available in user templates
+var templateFuncs = map[string]interface{}{
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"join":     strings.Join,
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"trim":     strings.TrimSpace,
+	"replace":  strings.ReplaceAll,
+	"contains": strings.Contains,
+	"indent": func(prefix, text string) string {
+		return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+	},
+	"default": func(fallback string, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return fallback
+		}
+		return value
+	},
+	"add": func(a, b int) int {
+		return a + b
+	},
+}
+
+// templateFuncMap returns the helper functions of user templates along with
+// "anchor", which depends on the converted file
+func (p *JSONToMarkdown) templateFuncMap() map[string]interface{} {
+	funcs := make(map[string]interface{}, len(templateFuncs)+1)
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	funcs["anchor"] = p.templateAnchor
+	return funcs
+}
+
+// templateAnchor returns the anchor name of a message, matching .Anchor.
+// Full account exports pass the chat ID too, as in anchor .ReplyToID $chat.ID.
+func (p *JSONToMarkdown) templateAnchor(id int64, chat ...int64) string {
+	if len(chat) > 0 && p.conv != nil && p.conv.multiChat {
+		return scopedAnchor(chat[0], id)
+	}
+	return p.chatAnchor(id)
+}
+
+// TemplateExtension returns the output file extension of a template, the
+// extension left after removing ".tmpl", or ".txt" if there is none
+func TemplateExtension(templatePath string) string {
+	name := filepath.Base(templatePath)
+	for _, ext := range templateExtensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			name = name[:len(name)-len(ext)]
+			break
+		}
+	}
+
+	if ext := filepath.Ext(name); ext != "" {
+		return ext
+	}
+	return ".txt"
+}
+
+// isHTMLTemplate reports whether a template produces HTML and is executed
+// with contextual escaping
+func isHTMLTemplate(templatePath string) bool {
+	switch strings.ToLower(TemplateExtension(templatePath)) {
+	case ".html", ".htm":
+		return true
+	default:
+		return false
+	}
+}
+
+// writeTemplate renders all chats with the user template
+func (p *JSONToMarkdown) writeTemplate(exports []telegram.Export, source, outputPath string) error {
+	if p.templatePath == "" {
+		return fmt.Errorf("no template file selected")
+	}
+
+	content, err := os.ReadFile(p.templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	data := &TemplateData{Source: source, Generated: time.Now()}
+	for i := range exports {
+		data.Chats = append(data.Chats, p.templateChat(&exports[i]))
+
+		if p.mediaInventory {
+			p.conv.inventory = append(p.conv.inventory, p.collectMedia(&exports[i])...)
+		}
+	}
+	if len(data.Chats) > 0 {
+		data.Chat = data.Chats[0]
+	}
+
+	// Templates are parsed for every file, so errors are reported per file
+	var tmpl interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+	name := filepath.Base(p.templatePath)
+	if isHTMLTemplate(p.templatePath) {
+		tmpl, err = htmltemplate.New(name).Funcs(p.templateFuncMap()).Parse(string(content))
+	} else {
+		tmpl, err = template.New(name).Funcs(p.templateFuncMap()).Parse(string(content))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var output bytes.Buffer
+	if err := tmpl.Execute(&output, data); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	if err := os.WriteFile(outputPath, output.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return nil
+}
+
+// templateChat builds the view model of a chat
+func (p *JSONToMarkdown) templateChat(export *telegram.Export) *TemplateChat {
+	p.scopeAnchors(export)
+	chat := &TemplateChat{
+		ID:   export.ID,
+		Name: export.Name,
+		Type: export.Type,
+	}
+
+	byID := make(map[int64]*TemplateMessage)
+	seen := make(map[string]bool)
+
+	for i := range export.Messages {
+		msg := &export.Messages[i]
+		message := p.templateMessage(msg)
+		chat.Messages = append(chat.Messages, message)
+		byID[msg.ID] = message
+
+		if msg.Type != "service" && msg.From != "" && !seen[msg.From] {
+			seen[msg.From] = true
+			chat.Participants = append(chat.Participants, msg.From)
+		}
+	}
+
+	// Replies within the chat point to their target
+	for _, message := range chat.Messages {
+		if message.ReplyToID != 0 {
+			message.ReplyTo = byID[message.ReplyToID]
+		}
+	}
+
+	return chat
+}
+
+// templateMessage builds the view model of a message
+func (p *JSONToMarkdown) templateMessage(msg *telegram.Message) *TemplateMessage {
+	message := &TemplateMessage{
+		ID:            msg.ID,
+		Anchor:        p.chatAnchor(msg.ID),
+		Type:          msg.Type,
+		Sender:        msg.From,
+		SenderID:      msg.FromID,
+		Text:          plainText(msg.Text),
+		Markdown:      p.extractTextContent(msg.Text, msg.TextEntities),
+		HTML:          htmltemplate.HTML(htmlText(msg.Text)),
+		Action:        msg.Action,
+		ReplyToID:     msg.ReplyToMessageID,
+		ForwardedFrom: msg.ForwardedFrom,
+	}
+
+	if msg.Date != "" {
+		message.Time = p.parseDate(msg.Date)
+	}
+	if msg.Edited != "" {
+		message.Edited = p.parseDate(msg.Edited)
+	}
+
+	if msg.Type == "service" {
+		message.Sender = msg.Actor
+		message.Service = relinkMessages(p.describeAction(msg), func(id int64, label string) string { return label })
+	}
+
+	if p.includeMedia {
+		message.Media = p.mediaRecord(msg)
+		if media := message.Media; media != nil && media.Status == MediaPresent && p.copiesMedia() {
+			if link, ok := p.relinkMedia(media.Path); ok {
+				message.MediaLink = link
+			}
+		}
+	}
+
+	for _, reaction := range msg.Reactions {
+		emoji := reaction.Emoji
+		if emoji == "" {
+			emoji = reaction.Type
+		}
+		message.Reactions = append(message.Reactions, ReactionCount{Emoji: emoji, Count: reaction.Count})
+	}
+
+	return message
+}
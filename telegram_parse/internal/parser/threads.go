@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"strings"
+
+	"telegram_parse/internal/telegram"
+)
+
+// maxThreadDepth limits blockquote nesting of replies, deeper replies are
+// shown at this depth
+const maxThreadDepth = 5
+
+// threadNode is a message with the replies to it
+type threadNode struct {
+	msg     *telegram.Message
+	replies []*threadNode
+}
+
+// buildThreads arranges messages into reply trees. Messages whose parent is
+// not among the messages, or is a forum topic root, become roots.
+func buildThreads(messages []*telegram.Message) []*threadNode {
+	nodes := make(map[int64]*threadNode, len(messages))
+	for _, msg := range messages {
+		nodes[msg.ID] = &threadNode{msg: msg}
+	}
+
+	var roots []*threadNode
+	for _, msg := range messages {
+		node := nodes[msg.ID]
+		parent, ok := nodes[msg.ReplyToMessageID]
+		if !ok || parent == node || parent.msg.Action == "topic_created" || msg.ReplyToPeerID != "" {
+			roots = append(roots, node)
+			continue
+		}
+		parent.replies = append(parent.replies, node)
+	}
+
+	return roots
+}
+
+// writeThreads converts reply trees to Markdown, quoting replies one level
+// deeper than the message they answer
+func (p *JSONToMarkdown) writeThreads(nodes []*threadNode, depth int, result *strings.Builder) {
+	for _, node := range nodes {
+		messageMarkdown := p.messageToMarkdown(node.msg)
+		if messageMarkdown != "" {
+			result.WriteString(quoteLines(messageMarkdown, depth))
+			result.WriteString("\n")
+		}
+
+		next := depth + 1
+		if next > maxThreadDepth {
+			next = maxThreadDepth
+		}
+		p.writeThreads(node.replies, next, result)
+	}
+}
+
+// quoteLines prefixes every line of Markdown with blockquote markers
+func quoteLines(markdown string, depth int) string {
+	if depth == 0 {
+		return markdown
+	}
+
+	prefix := strings.Repeat(">", depth) + " "
+	lines := strings.Split(strings.TrimRight(markdown, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = strings.TrimRight(prefix, " ")
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n\n"
+}
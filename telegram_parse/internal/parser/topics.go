@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"telegram_parse/internal/telegram"
+)
+
+// Topic modes controlling how forum supergroups are laid out
+const (
+	TopicModeChronological = "chronological" // Interleave all topics by date
+	TopicModeSections      = "sections"      // One section per topic with a table of contents
+	TopicModeFiles         = "files"         // One file per topic linked from the chat file
+)
+
+// generalTopicID is the ID of the implicit "General" topic of a forum. Other
+// topics take the ID of their creation message, which is never 0.
+const generalTopicID = 0
+
+// topic is a forum topic with its messages in chronological order
+type topic struct {
+	ID       int64
+	Title    string
+	Messages []*telegram.Message
+}
+
+// buildTopics groups messages of a forum by topic. Messages belong to the
+// topic whose creation message their reply chain leads to; messages outside
+// any topic go to "General". Returns nil if the chat has no topics.
+func buildTopics(export *telegram.Export) []*topic {
+	byID := make(map[int64]*telegram.Message, len(export.Messages))
+	topics := make(map[int64]*topic)
+	var order []*topic
+
+	for i := range export.Messages {
+		msg := &export.Messages[i]
+		byID[msg.ID] = msg
+
+		if msg.Action == "topic_created" {
+			t := &topic{ID: msg.ID, Title: msg.Title}
+			topics[msg.ID] = t
+			order = append(order, t)
+		}
+	}
+
+	if len(topics) == 0 {
+		return nil
+	}
+
+	general := &topic{ID: generalTopicID, Title: "General"}
+	resolved := make(map[int64]*topic)
+
+	// topicOf follows the reply chain up to a topic creation message
+	var topicOf func(msg *telegram.Message, depth int) *topic
+	topicOf = func(msg *telegram.Message, depth int) *topic {
+		if t, ok := topics[msg.ID]; ok {
+			return t
+		}
+		if t, ok := resolved[msg.ID]; ok {
+			return t
+		}
+
+		t := general
+		if parent, ok := byID[msg.ReplyToMessageID]; ok && depth < len(export.Messages) {
+			t = topicOf(parent, depth+1)
+		}
+
+		resolved[msg.ID] = t
+		return t
+	}
+
+	for i := range export.Messages {
+		msg := &export.Messages[i]
+		t := topicOf(msg, 0)
+		t.Messages = append(t.Messages, msg)
+
+		// Renamed topics are listed under their latest title
+		if msg.Action == "topic_edit" && msg.NewTitle != "" {
+			t.Title = msg.NewTitle
+		}
+	}
+
+	if len(general.Messages) > 0 {
+		order = append([]*topic{general}, order...)
+	}
+
+	return order
+}
+
+// topicAnchor returns the anchor name of a topic section
+func topicAnchor(id int64) string {
+	return fmt.Sprintf("topic-%d", id)
+}
+
+// topicFileName returns the name of the file a topic is written to
+func topicFileName(stem string, export *telegram.Export, t *topic) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(t.Title), "_"), "_")
+	if slug == "" {
+		slug = "topic"
+	}
+
+	name := fmt.Sprintf("%s_topic_%d_%s.md", stem, t.ID, slug)
+	if export.ID != 0 {
+		name = fmt.Sprintf("%s_%d_topic_%d_%s.md", stem, export.ID, t.ID, slug)
+	}
+
+	return name
+}
+
+// topicsToMarkdown writes the messages of a forum grouped by topic, either as
+// sections of the chat file or as separate files linked from it
+func (p *JSONToMarkdown) topicsToMarkdown(export *telegram.Export, topics []*topic, result *strings.Builder) {
+	files := p.topicMode == TopicModeFiles && p.conv != nil
+
+	// Table of contents
+	result.WriteString("## " + p.tr("Topics") + "\n\n")
+	for _, t := range topics {
+		count := p.plural(len(t.Messages), "message")
+
+		// Telegram sections have no anchors to link to
+		if !files && p.flavor == FlavorTelegram {
+			result.WriteString(fmt.Sprintf("- %s (%s)\n", p.topicTitle(t), count))
+			continue
+		}
+
+		link := "#" + topicAnchor(t.ID)
+		if files {
+			link = mediaLink(topicFileName(p.conv.stem, export, t))
+		}
+		result.WriteString(fmt.Sprintf("- [%s](%s) (%s)\n", p.topicTitle(t), link, count))
+	}
+	result.WriteString("\n---\n\n")
+
+	for _, t := range topics {
+		if files {
+			var topicResult strings.Builder
+			topicResult.WriteString(fmt.Sprintf("# %s / %s\n\n", export.Name, p.topicTitle(t)))
+			topicResult.WriteString(fmt.Sprintf("**%s** %d  \n\n---\n\n", p.tr("Messages:"), len(t.Messages)))
+			p.writeMessages(t.Messages, &topicResult)
+
+			name := topicFileName(p.conv.stem, export, t)
+			p.conv.extraFiles = append(p.conv.extraFiles, outputFile{
+				Path:    filepath.Join(p.conv.outputDir, name),
+				Content: topicResult.String(),
+			})
+			continue
+		}
+
+		// Messages are nested one heading level below the topic
+		section := *p
+		section.messageHeading = "###"
+
+		result.WriteString(p.anchoredHeading("##", "🗂️ "+p.topicTitle(t), topicAnchor(t.ID)))
+		section.writeMessages(t.Messages, result)
+	}
+}
+
+// topicTitle returns the title of a topic in the output language
+func (p *JSONToMarkdown) topicTitle(t *topic) string {
+	if t.ID == generalTopicID {
+		return p.tr("General")
+	}
+	return t.Title
+}
@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"telegram_parse/internal/telegram"
+)
+
+// transcriptDateFormat is the timestamp format of transcript lines
+const transcriptDateFormat = "2006-01-02 15:04"
+
+// transcriptIndent prefixes continuation lines of multi-line messages
+const transcriptIndent = "    "
+
+// writeTranscript writes all chats as plain text, one line per message
+func (p *JSONToMarkdown) writeTranscript(exports []telegram.Export, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+
+	for i := range exports {
+		export := &exports[i]
+
+		// Chat headers separate the chats of full account exports
+		if p.includeMetadata || len(exports) > 1 {
+			if i > 0 {
+				writer.WriteString("\n")
+			}
+			writer.WriteString(fmt.Sprintf("=== %s (%s, %s) ===\n\n", export.Name, export.Type, p.plural(len(export.Messages), "message")))
+		}
+
+		for j := range export.Messages {
+			if line := p.transcriptLine(&export.Messages[j]); line != "" {
+				writer.WriteString(line + "\n")
+			}
+		}
+
+		if p.mediaInventory {
+			p.conv.inventory = append(p.conv.inventory, p.collectMedia(export)...)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// transcriptLine renders a message as "[date] Sender: text" with
+// continuation lines indented
+func (p *JSONToMarkdown) transcriptLine(msg *telegram.Message) string {
+	if msg.Type == "service" && msg.Text == nil && msg.Action == "" {
+		return ""
+	}
+
+	var prefix strings.Builder
+	if msg.Date != "" {
+		prefix.WriteString("[" + p.parseDate(msg.Date).Format(transcriptDateFormat) + "] ")
+	}
+
+	// Service messages read as "* Ann pinned message 2"
+	if msg.Type == "service" {
+		sentence := p.describeAction(msg)
+		if sentence == "" {
+			sentence = strings.TrimSpace(msg.Actor + " " + msg.Action)
+		}
+		sentence = relinkMessages(sentence, func(id int64, label string) string { return label })
+		return indentTranscript(prefix.String() + "* " + sentence)
+	}
+
+	sender := msg.From
+	if msg.Author != "" && msg.Author != msg.From {
+		sender = fmt.Sprintf("%s (%s)", msg.From, msg.Author)
+	}
+	if sender == "" {
+		sender = msg.FromID
+	}
+	prefix.WriteString(sender + ": ")
+
+	var parts []string
+	if msg.ForwardedFrom != "" {
+		parts = append(parts, p.tr("(forwarded from %s)", msg.ForwardedFrom))
+	}
+	if msg.ReplyToMessageID != 0 {
+		parts = append(parts, p.tr("(reply to #%d)", msg.ReplyToMessageID))
+	}
+	if p.includeMedia {
+		parts = append(parts, p.transcriptMedia(msg)...)
+	}
+	if text := strings.TrimSpace(plainText(msg.Text)); text != "" {
+		parts = append(parts, text)
+	}
+	if msg.Edited != "" {
+		parts = append(parts, p.tr("(edited)"))
+	}
+
+	return indentTranscript(prefix.String() + strings.Join(parts, " "))
+}
+
+// transcriptMedia returns bracketed placeholders for the attachments of a
+// message
+func (p *JSONToMarkdown) transcriptMedia(msg *telegram.Message) []string {
+	var placeholders []string
+
+	if msg.Photo != "" {
+		placeholders = append(placeholders, "["+p.tr("Photo")+"]")
+	}
+
+	if msg.File != "" || (msg.MediaType != "" && msg.MediaType != "photo") {
+		_, label := p.mediaLabel(msg.MediaType)
+
+		var details []string
+		switch {
+		case msg.MediaType == "sticker" && msg.StickerEmoji != "":
+			details = append(details, msg.StickerEmoji)
+		case msg.FileName != "":
+			details = append(details, msg.FileName)
+		case msg.File != "" && !isMediaPlaceholder(msg.File):
+			details = append(details, filepath.Base(msg.File))
+		}
+		if duration := mediaDuration(msg); duration > 0 {
+			details = append(details, formatDuration(duration))
+		}
+
+		if len(details) > 0 {
+			placeholders = append(placeholders, fmt.Sprintf("[%s: %s]", label, strings.Join(details, ", ")))
+		} else {
+			placeholders = append(placeholders, fmt.Sprintf("[%s]", label))
+		}
+	}
+
+	if msg.Poll != nil {
+		placeholders = append(placeholders, fmt.Sprintf("[%s: %s]", p.tr("Poll"), msg.Poll.Question))
+	}
+
+	if contact := msg.ContactInformation; contact != nil {
+		name := strings.TrimSpace(contact.FirstName + " " + contact.LastName)
+		placeholders = append(placeholders, fmt.Sprintf("[%s: %s]", p.tr("Contact"), strings.TrimSpace(name+" "+contact.PhoneNumber)))
+	}
+
+	if location := msg.LocationInformation; location != nil {
+		placeholders = append(placeholders, fmt.Sprintf("[%s: %.6f, %.6f]", p.tr("Location"), location.Latitude, location.Longitude))
+	}
+
+	return placeholders
+}
+
+// indentTranscript indents all lines but the first
+func indentTranscript(text string) string {
+	return strings.ReplaceAll(text, "\n", "\n"+transcriptIndent)
+}
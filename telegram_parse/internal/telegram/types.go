@@ -8,6 +8,18 @@ type Export struct {
 	Messages []Message `json:"messages"`
 }
 
+// AccountExport represents a full account export (result.json with all chats)
+type AccountExport struct {
+	Chats     *ChatList `json:"chats,omitempty"`
+	LeftChats *ChatList `json:"left_chats,omitempty"`
+}
+
+// ChatList represents a list of chats in a full account export
+type ChatList struct {
+	About string   `json:"about"`
+	List  []Export `json:"list"`
+}
+
 // Message represents a single message in Telegram export
 type Message struct {
 	ID                  int64        `json:"id"`
@@ -20,20 +32,49 @@ type Message struct {
 	TextEntities        []TextEntity `json:"text_entities,omitempty"`
 	Photo               string       `json:"photo,omitempty"`
 	File                string       `json:"file,omitempty"`
+	FileName            string       `json:"file_name,omitempty"`
+	FileSize            int64        `json:"file_size,omitempty"`
 	Thumbnail           string       `json:"thumbnail,omitempty"`
-	MediaType           string       `json:"media_type,omitempty"`
+	MediaType           string       `json:"media_type,omitempty"` // sticker, voice_message, video_message, video_file, audio_file, animation
 	MimeType            string       `json:"mime_type,omitempty"`
+	StickerEmoji        string       `json:"sticker_emoji,omitempty"`
+	Performer           string       `json:"performer,omitempty"`
 	Duration            int          `json:"duration,omitempty"`
+	DurationSeconds     int          `json:"duration_seconds,omitempty"`
 	Width               int          `json:"width,omitempty"`
 	Height              int          `json:"height,omitempty"`
+	Edited              string       `json:"edited,omitempty"`
+	EditedUnixtime      string       `json:"edited_unixtime,omitempty"`
+	Author              string       `json:"author,omitempty"` // Channel post signature
+	Views               int          `json:"views,omitempty"`  // Channel post views, if exported
 	ForwardedFrom       string       `json:"forwarded_from,omitempty"`
+	ForwardedFromID     string       `json:"forwarded_from_id,omitempty"`
+	SavedFrom           string       `json:"saved_from,omitempty"`
 	ReplyToMessageID    int64        `json:"reply_to_message_id,omitempty"`
+	ReplyToPeerID       string       `json:"reply_to_peer_id,omitempty"` // Set when replying to a message in another chat
 	ViaBot              string       `json:"via_bot,omitempty"`
+	Reactions           []Reaction   `json:"reactions,omitempty"`
+	InlineBotButtons    [][]Button   `json:"inline_bot_buttons,omitempty"` // Rows of inline keyboard buttons
+	SelfDestructPeriod  int          `json:"self_destruct_period_seconds,omitempty"`
 	Actor               string       `json:"actor,omitempty"`
 	Action              string       `json:"action,omitempty"`
-	Title               string       `json:"title,omitempty"`
+	Title               string       `json:"title,omitempty"` // Chat title for service messages, track title for audio
 	Members             []string     `json:"members,omitempty"`
 	Inviter             string       `json:"inviter,omitempty"`
+	MessageID           int64        `json:"message_id,omitempty"` // Pinned message
+	DiscardReason       string       `json:"discard_reason,omitempty"`
+	GameMessageID       int64        `json:"game_message_id,omitempty"`
+	Score               int          `json:"score,omitempty"`
+	Amount              int64        `json:"amount,omitempty"` // Payment amount in minimal currency units
+	Currency            string       `json:"currency,omitempty"`
+	InvoiceMessageID    int64        `json:"invoice_message_id,omitempty"`
+	Period              int          `json:"period,omitempty"` // Messages auto-delete period in seconds
+	ScheduleDate        string       `json:"schedule_date,omitempty"`
+	Emoticon            string       `json:"emoticon,omitempty"`
+	NewTitle            string       `json:"new_title,omitempty"`
+	Months              int          `json:"months,omitempty"`
+	Boosts              int          `json:"boosts,omitempty"`
+	Values              []string     `json:"values,omitempty"` // Telegram Passport values
 	Poll                *Poll        `json:"poll,omitempty"`
 	ContactInformation  *Contact     `json:"contact_information,omitempty"`
 	LocationInformation *Location    `json:"location_information,omitempty"`
@@ -48,6 +89,29 @@ type TextEntity struct {
 	UserID string `json:"user_id,omitempty"`
 }
 
+// Reaction represents reactions of one kind on a message
+type Reaction struct {
+	Type       string           `json:"type"` // emoji, custom_emoji, paid
+	Count      int              `json:"count"`
+	Emoji      string           `json:"emoji,omitempty"`
+	DocumentID string           `json:"document_id,omitempty"`
+	Recent     []ReactionAuthor `json:"recent,omitempty"`
+}
+
+// ReactionAuthor represents a user who recently reacted
+type ReactionAuthor struct {
+	From   string `json:"from"`
+	FromID string `json:"from_id"`
+	Date   string `json:"date"`
+}
+
+// Button represents an inline keyboard button
+type Button struct {
+	Type string `json:"type"` // url, callback, switch_inline, ...
+	Text string `json:"text"`
+	Data string `json:"data,omitempty"` // URL for url buttons
+}
+
 // Poll represents a poll message
 type Poll struct {
 	Question    string       `json:"question"`